@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -9,39 +10,139 @@ import (
 
 // CmdConfig represents the configuration of the command.
 type CmdConfig struct {
-	Debug                   bool
-	Development             bool
-	WebhookListenAddr       string
-	MetricsListenAddr       string
-	MetricsPath             string
-	TLSCertFilePath         string
-	TLSKeyFilePath          string
-	EnableIngressSingleHost bool
-	IngressHostRegexes      []string
-	MinSMScrapeInterval     time.Duration
-
-	LabelMarks map[string]string
+	Debug                          bool
+	Development                    bool
+	KubeConfigPath                 string
+	WebhookListenAddr              string
+	MetricsListenAddr              string
+	MetricsPath                    string
+	TLSCertFilePath                string
+	TLSKeyFilePath                 string
+	TLSReloadInterval              time.Duration
+	EnableIngressSingleHost        bool
+	EnableIngressHostUnique        bool
+	EnableIngressTLS               bool
+	IngressTLSRequiredAnnot        []string
+	EnableIngressBackend           bool
+	IngressForbidDefBackend        bool
+	IngressAllowedClasses          []string
+	IngressHostClasses             map[string]string
+	IngressForbiddenAnnots         []string
+	IngressAnnotValueRegex         map[string]string
+	IngressRequiredAnnots          []string
+	IngressAllErrors               bool
+	IngressValidatorModes          map[string]string
+	IngressHostRegexes             []string
+	IngressHostPatterns            []string
+	IngressNSHostPolicyFile        string
+	ExemptNamespaces               []string
+	ExemptNSSelector               string
+	ExemptObjSelector              string
+	ExemptSkipAnnotation           bool
+	ExemptUsers                    []string
+	ExemptGroups                   []string
+	SMSafer                        SaferCmdConfig
+	PMSafer                        SaferCmdConfig
+	ProbeSafer                     SaferCmdConfig
+	EnableSMRelabelValidation      bool
+	SMForbiddenRelabelActions      []string
+	SMForbiddenRelabelTargetLabels []string
+	SMHonorLabelsNamespaces        []string
+	EnablePromRuleValidation       bool
+	PromRuleRequiredAlertLabels    []string
+	PromRuleRequiredAlertAnnots    []string
+
+	LabelMarks       map[string]string
+	AnnotationMarks  map[string]string
+	MarkModes        map[string]string
+	MarkPodTemplates bool
+	StripLabels      []string
+	StripAnnotations []string
+	NSLabelMarks     []string
+	MarkRulesFile    string
+}
+
+// SaferCmdConfig represents the configuration of a Prometheus operator monitoring resource safer.
+type SaferCmdConfig struct {
+	MinScrapeInterval            time.Duration
+	MinScrapeTimeout             time.Duration
+	MaxScrapeTimeout             time.Duration
+	DefaultScrapeTimeout         time.Duration
+	DefaultSampleLimit           uint64
+	MaxSampleLimit               uint64
+	DefaultTargetLimit           uint64
+	MaxTargetLimit               uint64
+	DefaultLabelLimit            uint64
+	MaxLabelLimit                uint64
+	DefaultLabelNameLengthLimit  uint64
+	MaxLabelNameLengthLimit      uint64
+	DefaultLabelValueLengthLimit uint64
+	MaxLabelValueLengthLimit     uint64
+	SafetyModes                  map[string]string
 }
 
 // NewCmdConfig returns a new command configuration.
 func NewCmdConfig() (*CmdConfig, error) {
 	c := &CmdConfig{
-		LabelMarks: map[string]string{},
+		LabelMarks:             map[string]string{},
+		AnnotationMarks:        map[string]string{},
+		MarkModes:              map[string]string{},
+		IngressHostClasses:     map[string]string{},
+		IngressAnnotValueRegex: map[string]string{},
+		IngressValidatorModes:  map[string]string{},
 	}
 	app := kingpin.New("k8s-webhook-example", "A Kubernetes production-ready admission webhook example.")
 	app.Version(Version)
 
 	app.Flag("debug", "Enable debug mode.").BoolVar(&c.Debug)
 	app.Flag("development", "Enable development mode.").BoolVar(&c.Development)
+	app.Flag("kube-config", "the kubeconfig path used to connect to Kubernetes, if empty it will use the in-cluster configuration.").StringVar(&c.KubeConfigPath)
 	app.Flag("webhook-listen-address", "the address where the HTTPS server will be listening to serve the webhooks.").Default(":8080").StringVar(&c.WebhookListenAddr)
 	app.Flag("metrics-listen-address", "the address where the HTTP server will be listening to serve metrics, healthchecks, profiling...").Default(":8081").StringVar(&c.MetricsListenAddr)
 	app.Flag("metrics-path", "the path where Prometheus metrics will be served.").Default("/metrics").StringVar(&c.MetricsPath)
 	app.Flag("tls-cert-file-path", "the path for the webhook HTTPS server TLS cert file.").StringVar(&c.TLSCertFilePath)
 	app.Flag("tls-key-file-path", "the path for the webhook HTTPS server TLS key file.").StringVar(&c.TLSKeyFilePath)
-	app.Flag("webhook-label-marks", "a map of labels the webhook will set to all resources, if no labels, the label marker webhook will be disabled. Can repeat flag").Short('l').StringMapVar(&c.LabelMarks)
+	app.Flag("tls-reload-interval", "the interval the TLS cert and key files will be checked for changes to reload the certificate.").Default("30s").DurationVar(&c.TLSReloadInterval)
+	app.Flag("webhook-label-marks", "a map of labels the webhook will set to all resources, values can be Go templates (e.g: 'created-by={{ .Username }}'), if no labels, the label marker webhook will be disabled. Can repeat flag").Short('l').StringMapVar(&c.LabelMarks)
+	app.Flag("webhook-annotation-marks", "a map of annotations the webhook will set to all resources, values can be Go templates like the label marks, if no labels and annotations, the marker webhook will be disabled. Can repeat flag").StringMapVar(&c.AnnotationMarks)
+	app.Flag("webhook-mark-mode", "a map of label or annotation mark keys and their mode ('overwrite', 'set-if-absent' or 'reject-if-different'), by default static marks overwrite the existing values and templated marks are only set if absent (e.g: 'team=reject-if-different'). Can repeat flag").StringMapVar(&c.MarkModes)
+	app.Flag("webhook-mark-pod-templates", "propagates the label and annotation marks to the pod templates of deployments, daemonsets, statefulsets, jobs and cronjobs.").BoolVar(&c.MarkPodTemplates)
+	app.Flag("webhook-strip-label", "a list of label keys or prefixes ending with '*' (e.g: 'platform.example.com/*') the marker webhook will remove from all resources. Can repeat flag").StringsVar(&c.StripLabels)
+	app.Flag("webhook-strip-annotation", "a list of annotation keys or prefixes ending with '*' the marker webhook will remove from all resources. Can repeat flag").StringsVar(&c.StripAnnotations)
+	app.Flag("webhook-namespace-label-marks", "a list of namespace label keys the marker webhook will copy from the namespace to all resources (e.g: 'team'). Can repeat flag").StringsVar(&c.NSLabelMarks)
+	app.Flag("webhook-mark-rules-file", "the path to a YAML file with marking rules, each rule has its own marks for the resources matching its group version kinds, namespace selector and object selector.").StringVar(&c.MarkRulesFile)
 	app.Flag("webhook-enable-ingress-single-host", "enables validation of ingress to have only a single host/rule.").Short('s').BoolVar(&c.EnableIngressSingleHost)
+	app.Flag("webhook-enable-ingress-host-uniqueness", "enables validation of ingress hosts and paths not being already claimed by other ingresses of the cluster.").BoolVar(&c.EnableIngressHostUnique)
+	app.Flag("webhook-enable-ingress-tls", "enables validation of ingress TLS section, all hosts covered by TLS entries with a secret.").BoolVar(&c.EnableIngressTLS)
+	app.Flag("webhook-ingress-tls-required-annotation", "a list of annotations (e.g: cert-manager issuer) that ingresses require, only used if ingress TLS validation is enabled. Can repeat flag.").StringsVar(&c.IngressTLSRequiredAnnot)
+	app.Flag("webhook-enable-ingress-backend", "enables validation of ingress paths and backends (path types, duplicated paths, regex paths with exact path type).").BoolVar(&c.EnableIngressBackend)
+	app.Flag("webhook-ingress-forbid-default-backend", "forbids ingresses with default backend, only used if ingress backend validation is enabled.").BoolVar(&c.IngressForbidDefBackend)
+	app.Flag("webhook-ingress-allowed-class", "a list of ingress classes that ingresses can use, no classes and no host classes disables validation webhook. Can repeat flag.").StringsVar(&c.IngressAllowedClasses)
+	app.Flag("webhook-ingress-host-class", "a map of host patterns (without '!' deny patterns) and the ingress class the hosts matching them must use, the most specific pattern is used (e.g: '*.example.com=public'). Can repeat flag.").StringMapVar(&c.IngressHostClasses)
+	app.Flag("webhook-ingress-forbidden-annotation", "a list of annotations ingresses can't have (e.g: 'nginx.ingress.kubernetes.io/server-snippet'), no annotation policy disables validation webhook. Can repeat flag.").StringsVar(&c.IngressForbiddenAnnots)
+	app.Flag("webhook-ingress-annotation-value-regex", "a map of annotations and the regex their values must match (e.g: 'nginx.ingress.kubernetes.io/ssl-redirect=true|false'). Can repeat flag.").StringMapVar(&c.IngressAnnotValueRegex)
+	app.Flag("webhook-ingress-required-annotation", "a list of annotations ingresses must have. Can repeat flag.").StringsVar(&c.IngressRequiredAnnots)
+	app.Flag("webhook-ingress-all-errors", "executes all the ingress validations and returns all the failures at once, instead of stopping on the first one.").BoolVar(&c.IngressAllErrors)
+	app.Flag("webhook-ingress-validator-mode", "a map of ingress validator codes and their mode ('enforce', 'warn' or 'audit'), by default validators are enforced (e.g: 'tls=warn'). Can repeat flag.").StringMapVar(&c.IngressValidatorModes)
 	app.Flag("webhook-ingress-host-regex", "a list of regexes that will validate ingress hosts matching against this regexes, no host disables validation webhook. Can repeat flag.").Short('h').StringsVar(&c.IngressHostRegexes)
-	app.Flag("webhook-sm-min-scrape-interval", "the minimum screate interval service monitors can have.").DurationVar(&c.MinSMScrapeInterval)
+	app.Flag("webhook-ingress-host-pattern", "a list of DNS aware host patterns ('example.com', '*.example.com', '.example.com' and '!' prefix to deny) that will validate ingress hosts, no pattern disables validation webhook. Can repeat flag.").StringsVar(&c.IngressHostPatterns)
+	app.Flag("webhook-ingress-namespace-host-policy-file", "the path to a YAML file with the allowed ingress host patterns per namespace, no file disables validation webhook.").StringVar(&c.IngressNSHostPolicyFile)
+	app.Flag("webhook-exempt-namespace", "a list of namespaces whose objects will be skipped by all the webhooks. Can repeat flag.").StringsVar(&c.ExemptNamespaces)
+	app.Flag("webhook-exempt-namespace-selector", "a label selector (e.g: 'webhooks=disabled') of the namespaces whose objects will be skipped by all the webhooks.").StringVar(&c.ExemptNSSelector)
+	app.Flag("webhook-exempt-object-selector", "a label selector of the objects that will be skipped by all the webhooks.").StringVar(&c.ExemptObjSelector)
+	app.Flag("webhook-exempt-skip-annotation", "enables objects to opt-out from all the webhooks with the 'k8s-webhook-example.slok.dev/skip: \"true\"' annotation.").BoolVar(&c.ExemptSkipAnnotation)
+	app.Flag("webhook-exempt-user", "a list of usernames whose requests will be skipped by all the webhooks. Can repeat flag.").StringsVar(&c.ExemptUsers)
+	app.Flag("webhook-exempt-group", "a list of user groups whose requests will be skipped by all the webhooks. Can repeat flag.").StringsVar(&c.ExemptGroups)
+	registerSaferFlags(app, "sm", "service monitors", &c.SMSafer)
+	registerSaferFlags(app, "pm", "pod monitors", &c.PMSafer)
+	registerSaferFlags(app, "probe", "probes", &c.ProbeSafer)
+	app.Flag("webhook-enable-sm-relabel-validation", "enables validation of service monitors relabelings regexes, actions, target labels and honor labels.").BoolVar(&c.EnableSMRelabelValidation)
+	app.Flag("webhook-sm-forbidden-relabel-action", "a list of relabel actions service monitors can't use (e.g: 'labelmap'). Can repeat flag.").StringsVar(&c.SMForbiddenRelabelActions)
+	app.Flag("webhook-sm-forbidden-relabel-target-label", "a list of labels service monitors relabelings can't replace or drop (e.g: 'job'). Can repeat flag.").StringsVar(&c.SMForbiddenRelabelTargetLabels)
+	app.Flag("webhook-sm-honor-labels-namespace", "a list of namespaces whose service monitors can use honor labels. Can repeat flag.").StringsVar(&c.SMHonorLabelsNamespaces)
+	app.Flag("webhook-enable-prometheus-rule-validation", "enables validation of Prometheus rules (PromQL expressions, for durations, alerting rules required labels and annotations and duplicated rule names).").BoolVar(&c.EnablePromRuleValidation)
+	app.Flag("webhook-prometheus-rule-required-alert-label", "a list of labels Prometheus alerting rules must have (e.g: 'severity'). Can repeat flag.").StringsVar(&c.PromRuleRequiredAlertLabels)
+	app.Flag("webhook-prometheus-rule-required-alert-annotation", "a list of annotations Prometheus alerting rules must have (e.g: 'runbook_url'). Can repeat flag.").StringsVar(&c.PromRuleRequiredAlertAnnots)
 
 	_, err := app.Parse(os.Args[1:])
 	if err != nil {
@@ -50,3 +151,28 @@ func NewCmdConfig() (*CmdConfig, error) {
 
 	return c, nil
 }
+
+// registerSaferFlags registers the safer flags of a Prometheus operator monitoring resource kind
+// with its own prefix (e.g: `--webhook-pm-min-scrape-interval`).
+func registerSaferFlags(app *kingpin.Application, prefix, resources string, c *SaferCmdConfig) {
+	c.SafetyModes = map[string]string{}
+	flag := func(name, help string) *kingpin.FlagClause {
+		return app.Flag(fmt.Sprintf("webhook-%s-%s", prefix, name), fmt.Sprintf(help, resources))
+	}
+
+	flag("min-scrape-interval", "the minimum scrape interval %s can have.").DurationVar(&c.MinScrapeInterval)
+	flag("min-scrape-timeout", "the minimum scrape timeout %s can have.").DurationVar(&c.MinScrapeTimeout)
+	flag("max-scrape-timeout", "the maximum scrape timeout %s can have.").DurationVar(&c.MaxScrapeTimeout)
+	flag("default-scrape-timeout", "the scrape timeout set on %s without one. The timeout will never be greater than the scrape interval.").DurationVar(&c.DefaultScrapeTimeout)
+	flag("default-sample-limit", "the samples per scrape limit set on %s without one, if missing the maximum will be used.").Uint64Var(&c.DefaultSampleLimit)
+	flag("max-sample-limit", "the maximum samples per scrape limit %s can have.").Uint64Var(&c.MaxSampleLimit)
+	flag("default-target-limit", "the scraped targets limit set on %s without one, if missing the maximum will be used.").Uint64Var(&c.DefaultTargetLimit)
+	flag("max-target-limit", "the maximum scraped targets limit %s can have.").Uint64Var(&c.MaxTargetLimit)
+	flag("default-label-limit", "the labels per sample limit set on %s without one, if missing the maximum will be used.").Uint64Var(&c.DefaultLabelLimit)
+	flag("max-label-limit", "the maximum labels per sample limit %s can have.").Uint64Var(&c.MaxLabelLimit)
+	flag("default-label-name-length-limit", "the label name length limit set on %s without one, if missing the maximum will be used.").Uint64Var(&c.DefaultLabelNameLengthLimit)
+	flag("max-label-name-length-limit", "the maximum label name length limit %s can have.").Uint64Var(&c.MaxLabelNameLengthLimit)
+	flag("default-label-value-length-limit", "the label value length limit set on %s without one, if missing the maximum will be used.").Uint64Var(&c.DefaultLabelValueLengthLimit)
+	flag("max-label-value-length-limit", "the maximum label value length limit %s can have.").Uint64Var(&c.MaxLabelValueLengthLimit)
+	flag("safety-mode", "a map of the safety rules of %s and their mode ('mutate' or 'validate'), by default rules are mutated, the rules in validate mode will be denied by the validating webhook instead (e.g: 'scrape-interval=validate'). Can repeat flag.").StringMapVar(&c.SafetyModes)
+}
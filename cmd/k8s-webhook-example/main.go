@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,13 +16,19 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	kubernetesclient "k8s.io/client-go/kubernetes"
 
+	"github.com/slok/k8s-webhook-example/internal/certificate"
+	"github.com/slok/k8s-webhook-example/internal/exemption"
+	"github.com/slok/k8s-webhook-example/internal/http/debug"
 	"github.com/slok/k8s-webhook-example/internal/http/webhook"
+	internalkubernetes "github.com/slok/k8s-webhook-example/internal/kubernetes"
 	"github.com/slok/k8s-webhook-example/internal/log"
 	internalmetricsprometheus "github.com/slok/k8s-webhook-example/internal/metrics/prometheus"
 	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
 	internalmutationprometheus "github.com/slok/k8s-webhook-example/internal/mutation/prometheus"
 	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+	validationprometheus "github.com/slok/k8s-webhook-example/internal/validation/prometheus"
 )
 
 var (
@@ -48,15 +56,215 @@ func runApp() error {
 	// Dependencies.
 	metricsRec := internalmetricsprometheus.NewRecorder(prometheus.DefaultRegisterer)
 
-	var marker mark.Marker
+	// Kubernetes dependencies are created lazily, only when a webhook requires them.
+	kubeCtx, kubeCancel := context.WithCancel(context.Background())
+	defer kubeCancel()
+
+	var kubeCli kubernetesclient.Interface
+	getKubeCli := func() (kubernetesclient.Interface, error) {
+		if kubeCli != nil {
+			return kubeCli, nil
+		}
+
+		cli, err := internalkubernetes.NewClient(cfg.KubeConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		kubeCli = cli
+
+		return kubeCli, nil
+	}
+
+	var nsCache *internalkubernetes.NamespaceCache
+	getNamespaceCache := func() (*internalkubernetes.NamespaceCache, error) {
+		if nsCache != nil {
+			return nsCache, nil
+		}
+
+		cli, err := getKubeCli()
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := internalkubernetes.NewNamespaceCache(kubeCtx, cli, logger)
+		if err != nil {
+			return nil, err
+		}
+		nsCache = c
+
+		return nsCache, nil
+	}
+
+	var exempter exemption.Exempter
+	exemptEnabled := len(cfg.ExemptNamespaces) > 0 || cfg.ExemptNSSelector != "" || cfg.ExemptObjSelector != "" ||
+		cfg.ExemptSkipAnnotation || len(cfg.ExemptUsers) > 0 || len(cfg.ExemptGroups) > 0
+	if exemptEnabled {
+		var nsLabelsGetter exemption.NamespaceLabelsGetter
+		if cfg.ExemptNSSelector != "" {
+			nsCache, err := getNamespaceCache()
+			if err != nil {
+				return fmt.Errorf("could not create namespace cache: %w", err)
+			}
+			nsLabelsGetter = nsCache
+		}
+
+		exempter, err = exemption.NewExempter(exemption.Config{
+			Namespaces:            cfg.ExemptNamespaces,
+			NamespaceSelector:     cfg.ExemptNSSelector,
+			ObjectSelector:        cfg.ExemptObjSelector,
+			EnableSkipAnnotation:  cfg.ExemptSkipAnnotation,
+			Usernames:             cfg.ExemptUsers,
+			Groups:                cfg.ExemptGroups,
+			NamespaceLabelsGetter: nsLabelsGetter,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create exempter: %w", err)
+		}
+		logger.Infof("webhooks exemptions enabled")
+	} else {
+		exempter = exemption.DummyExempter
+		logger.Warningf("webhooks exemptions disabled")
+	}
+
+	nsLabelMarks := map[string]bool{}
+	for _, l := range cfg.NSLabelMarks {
+		nsLabelMarks[l] = true
+	}
+
+	markModes := map[string]mark.MarkMode{}
+	for k, mode := range cfg.MarkModes {
+		_, isLabel := cfg.LabelMarks[k]
+		_, isAnnotation := cfg.AnnotationMarks[k]
+		if !isLabel && !isAnnotation && !nsLabelMarks[k] {
+			return fmt.Errorf("mark mode %q is not for a label, annotation or namespace label mark", k)
+		}
+		markModes[k] = mark.MarkMode(mode)
+	}
+
+	markers := []mark.Marker{}
+
+	// Strip before setting the marks, so the marks are not removed.
+	if len(cfg.StripLabels) > 0 || len(cfg.StripAnnotations) > 0 {
+		markers = append(markers, mark.NewStripMarker(mark.StripConfig{
+			Labels:       cfg.StripLabels,
+			Annotations:  cfg.StripAnnotations,
+			PodTemplates: cfg.MarkPodTemplates,
+		}))
+		logger.Infof("strip marker webhook enabled")
+	} else {
+		logger.Warningf("strip marker webhook disabled")
+	}
+
 	if len(cfg.LabelMarks) > 0 {
-		marker = mark.NewLabelMarker(cfg.LabelMarks)
+		var nsLabelsGetter mark.NamespaceLabelsGetter
+		if usesNamespaceLabels(cfg.LabelMarks) {
+			nsCache, err := getNamespaceCache()
+			if err != nil {
+				return fmt.Errorf("could not create namespace cache: %w", err)
+			}
+			nsLabelsGetter = nsCache
+		}
+
+		labelMarker, err := mark.NewLabelMarker(mark.MarkerConfig{
+			Marks:                 cfg.LabelMarks,
+			Modes:                 markModes,
+			PodTemplates:          cfg.MarkPodTemplates,
+			NamespaceLabelsGetter: nsLabelsGetter,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create label marker: %w", err)
+		}
+		markers = append(markers, labelMarker)
 		logger.Infof("label marker webhook enabled")
 	} else {
-		marker = mark.DummyMarker
 		logger.Warningf("label marker webhook disabled")
 	}
 
+	if len(cfg.AnnotationMarks) > 0 {
+		var nsLabelsGetter mark.NamespaceLabelsGetter
+		if usesNamespaceLabels(cfg.AnnotationMarks) {
+			nsCache, err := getNamespaceCache()
+			if err != nil {
+				return fmt.Errorf("could not create namespace cache: %w", err)
+			}
+			nsLabelsGetter = nsCache
+		}
+
+		annotationMarker, err := mark.NewAnnotationMarker(mark.MarkerConfig{
+			Marks:                 cfg.AnnotationMarks,
+			Modes:                 markModes,
+			PodTemplates:          cfg.MarkPodTemplates,
+			NamespaceLabelsGetter: nsLabelsGetter,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create annotation marker: %w", err)
+		}
+		markers = append(markers, annotationMarker)
+		logger.Infof("annotation marker webhook enabled")
+	} else {
+		logger.Warningf("annotation marker webhook disabled")
+	}
+
+	if len(cfg.NSLabelMarks) > 0 {
+		nsCache, err := getNamespaceCache()
+		if err != nil {
+			return fmt.Errorf("could not create namespace cache: %w", err)
+		}
+
+		nsLabelsMarker, err := mark.NewNamespaceLabelsMarker(mark.NamespaceLabelsMarkerConfig{
+			Labels:                cfg.NSLabelMarks,
+			Modes:                 markModes,
+			PodTemplates:          cfg.MarkPodTemplates,
+			NamespaceLabelsGetter: nsCache,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create namespace labels marker: %w", err)
+		}
+		markers = append(markers, nsLabelsMarker)
+		logger.Infof("namespace labels marker webhook enabled")
+	} else {
+		logger.Warningf("namespace labels marker webhook disabled")
+	}
+
+	if cfg.MarkRulesFile != "" {
+		rules, err := loadMarkRules(cfg.MarkRulesFile)
+		if err != nil {
+			return fmt.Errorf("could not load mark rules: %w", err)
+		}
+
+		var nsLabelsGetter mark.NamespaceLabelsGetter
+		for _, r := range rules {
+			if r.NamespaceSelector == "" && !usesNamespaceLabels(r.Labels) && !usesNamespaceLabels(r.Annotations) {
+				continue
+			}
+
+			nsCache, err := getNamespaceCache()
+			if err != nil {
+				return fmt.Errorf("could not create namespace cache: %w", err)
+			}
+			nsLabelsGetter = nsCache
+			break
+		}
+
+		ruleMarker, err := mark.NewRuleMarker(mark.RuleMarkerConfig{
+			Rules:                 rules,
+			PodTemplates:          cfg.MarkPodTemplates,
+			NamespaceLabelsGetter: nsLabelsGetter,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create rule marker: %w", err)
+		}
+		markers = append(markers, ruleMarker)
+		logger.Infof("rule marker webhook enabled")
+	} else {
+		logger.Warningf("rule marker webhook disabled")
+	}
+
+	var marker mark.Marker = mark.DummyMarker
+	if len(markers) > 0 {
+		marker = mark.NewChain(markers...)
+	}
+
 	var ingressHostValidator ingress.Validator
 	if len(cfg.IngressHostRegexes) > 0 {
 		ingressHostValidator, err = ingress.NewHostRegexValidator(cfg.IngressHostRegexes)
@@ -69,6 +277,119 @@ func runApp() error {
 		logger.Warningf("ingress host regex validation webhook disabled")
 	}
 
+	var ingressHostPolicyValidator ingress.Validator
+	if len(cfg.IngressHostPatterns) > 0 {
+		ingressHostPolicyValidator, err = ingress.NewHostPolicyValidator(cfg.IngressHostPatterns)
+		if err != nil {
+			return fmt.Errorf("could not create ingress host policy validator: %w", err)
+		}
+		logger.Infof("ingress host policy validation webhook enabled")
+	} else {
+		ingressHostPolicyValidator = ingress.DummyValidator
+		logger.Warningf("ingress host policy validation webhook disabled")
+	}
+
+	var ingressNSHostPolicyValidator ingress.Validator
+	if cfg.IngressNSHostPolicyFile != "" {
+		policies, defaultHostPatterns, err := loadIngressNamespaceHostPolicies(cfg.IngressNSHostPolicyFile)
+		if err != nil {
+			return fmt.Errorf("could not load ingress namespace host policies: %w", err)
+		}
+
+		var nsLabelsGetter ingress.NamespaceLabelsGetter
+		for _, p := range policies {
+			if p.NamespaceSelector == "" {
+				continue
+			}
+
+			nsCache, err := getNamespaceCache()
+			if err != nil {
+				return fmt.Errorf("could not create namespace cache: %w", err)
+			}
+			nsLabelsGetter = nsCache
+			break
+		}
+
+		ingressNSHostPolicyValidator, err = ingress.NewNamespaceHostPolicyValidator(ingress.NamespaceHostPolicyValidatorConfig{
+			Policies:              policies,
+			DefaultHostPatterns:   defaultHostPatterns,
+			NamespaceLabelsGetter: nsLabelsGetter,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create ingress namespace host policy validator: %w", err)
+		}
+		logger.Infof("ingress namespace host policy validation webhook enabled")
+	} else {
+		ingressNSHostPolicyValidator = ingress.DummyValidator
+		logger.Warningf("ingress namespace host policy validation webhook disabled")
+	}
+
+	var ingressHostUniquenessValidator ingress.Validator
+	if cfg.EnableIngressHostUnique {
+		cli, err := getKubeCli()
+		if err != nil {
+			return fmt.Errorf("could not create Kubernetes client: %w", err)
+		}
+
+		ingressHostUniquenessValidator, err = ingress.NewHostUniquenessValidator(kubeCtx, internalkubernetes.NewIngressWatcher(cli, logger))
+		if err != nil {
+			return fmt.Errorf("could not create ingress host uniqueness validator: %w", err)
+		}
+		logger.Infof("ingress host uniqueness validation webhook enabled")
+	} else {
+		ingressHostUniquenessValidator = ingress.DummyValidator
+		logger.Warningf("ingress host uniqueness validation webhook disabled")
+	}
+
+	var ingressTLSValidator ingress.Validator
+	if cfg.EnableIngressTLS {
+		ingressTLSValidator = ingress.NewTLSValidator(cfg.IngressTLSRequiredAnnot)
+		logger.Infof("ingress TLS validation webhook enabled")
+	} else {
+		ingressTLSValidator = ingress.DummyValidator
+		logger.Warningf("ingress TLS validation webhook disabled")
+	}
+
+	var ingressBackendValidator ingress.Validator
+	if cfg.EnableIngressBackend {
+		ingressBackendValidator = ingress.NewBackendValidator(cfg.IngressForbidDefBackend)
+		logger.Infof("ingress backend validation webhook enabled")
+	} else {
+		ingressBackendValidator = ingress.DummyValidator
+		logger.Warningf("ingress backend validation webhook disabled")
+	}
+
+	var ingressClassValidator ingress.Validator
+	if len(cfg.IngressAllowedClasses) > 0 || len(cfg.IngressHostClasses) > 0 {
+		ingressClassValidator, err = ingress.NewClassValidator(ingress.ClassValidatorConfig{
+			AllowedClasses: cfg.IngressAllowedClasses,
+			HostClasses:    cfg.IngressHostClasses,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create ingress class validator: %w", err)
+		}
+		logger.Infof("ingress class validation webhook enabled")
+	} else {
+		ingressClassValidator = ingress.DummyValidator
+		logger.Warningf("ingress class validation webhook disabled")
+	}
+
+	var ingressAnnotationValidator ingress.Validator
+	if len(cfg.IngressForbiddenAnnots) > 0 || len(cfg.IngressAnnotValueRegex) > 0 || len(cfg.IngressRequiredAnnots) > 0 {
+		ingressAnnotationValidator, err = ingress.NewAnnotationValidator(ingress.AnnotationPolicy{
+			Forbidden:     cfg.IngressForbiddenAnnots,
+			AllowedValues: cfg.IngressAnnotValueRegex,
+			Required:      cfg.IngressRequiredAnnots,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create ingress annotation validator: %w", err)
+		}
+		logger.Infof("ingress annotation validation webhook enabled")
+	} else {
+		ingressAnnotationValidator = ingress.DummyValidator
+		logger.Warningf("ingress annotation validation webhook disabled")
+	}
+
 	var ingressSingleHostValidator ingress.Validator
 	if cfg.EnableIngressSingleHost {
 		ingressSingleHostValidator = ingress.SingleHostValidator
@@ -79,14 +400,66 @@ func runApp() error {
 	}
 
 	var serviceMonitorSafer internalmutationprometheus.ServiceMonitorSafer
-	if cfg.MinSMScrapeInterval != 0 {
-		serviceMonitorSafer = internalmutationprometheus.NewServiceMonitorSafer(cfg.MinSMScrapeInterval)
+	if saferConfig, ok := newSaferConfig(cfg.SMSafer); ok {
+		serviceMonitorSafer, err = internalmutationprometheus.NewServiceMonitorSafer(saferConfig)
+		if err != nil {
+			return fmt.Errorf("could not create service monitor safer: %w", err)
+		}
 		logger.Infof("service monitor safer webhook enabled")
 	} else {
 		serviceMonitorSafer = internalmutationprometheus.DummyServiceMonitorSafer
 		logger.Warningf("service monitor safer webhook disabled")
 	}
 
+	var podMonitorSafer internalmutationprometheus.PodMonitorSafer
+	if saferConfig, ok := newSaferConfig(cfg.PMSafer); ok {
+		podMonitorSafer, err = internalmutationprometheus.NewPodMonitorSafer(saferConfig)
+		if err != nil {
+			return fmt.Errorf("could not create pod monitor safer: %w", err)
+		}
+		logger.Infof("pod monitor safer webhook enabled")
+	} else {
+		podMonitorSafer = internalmutationprometheus.DummyPodMonitorSafer
+		logger.Warningf("pod monitor safer webhook disabled")
+	}
+
+	var probeSafer internalmutationprometheus.ProbeSafer
+	if saferConfig, ok := newSaferConfig(cfg.ProbeSafer); ok {
+		probeSafer, err = internalmutationprometheus.NewProbeSafer(saferConfig)
+		if err != nil {
+			return fmt.Errorf("could not create probe safer: %w", err)
+		}
+		logger.Infof("probe safer webhook enabled")
+	} else {
+		probeSafer = internalmutationprometheus.DummyProbeSafer
+		logger.Warningf("probe safer webhook disabled")
+	}
+
+	var serviceMonitorValidator validationprometheus.ServiceMonitorValidator
+	if cfg.EnableSMRelabelValidation {
+		serviceMonitorValidator = validationprometheus.NewRelabelValidator(validationprometheus.RelabelPolicy{
+			ForbiddenActions:      cfg.SMForbiddenRelabelActions,
+			ForbiddenTargetLabels: cfg.SMForbiddenRelabelTargetLabels,
+			HonorLabelsNamespaces: cfg.SMHonorLabelsNamespaces,
+		})
+		logger.Infof("service monitor relabel validation webhook enabled")
+	} else {
+		serviceMonitorValidator = validationprometheus.DummyServiceMonitorValidator
+		logger.Warningf("service monitor relabel validation webhook disabled")
+	}
+
+	var prometheusRuleValidator validationprometheus.PrometheusRuleValidator
+	if cfg.EnablePromRuleValidation {
+		prometheusRuleValidator = validationprometheus.NewRuleValidator(validationprometheus.RulePolicy{
+			RequiredAlertLabels:      cfg.PromRuleRequiredAlertLabels,
+			RequiredAlertAnnotations: cfg.PromRuleRequiredAlertAnnots,
+		})
+		logger.Infof("prometheus rule validation webhook enabled")
+	} else {
+		prometheusRuleValidator = validationprometheus.DummyPrometheusRuleValidator
+		logger.Warningf("prometheus rule validation webhook disabled")
+	}
+
 	// Prepare run entrypoints.
 	var g run.Group
 
@@ -127,6 +500,29 @@ func runApp() error {
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 
+		// Mutations debug.
+		// Same mutator as the webhooks, without metrics so the debug requests are not measured.
+		debugMutator, err := webhook.NewMutator(webhook.MutatorConfig{
+			Exempter:            exempter,
+			Marker:              marker,
+			ServiceMonitorSafer: serviceMonitorSafer,
+			PodMonitorSafer:     podMonitorSafer,
+			ProbeSafer:          probeSafer,
+			Logger:              logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create debug mutator: %w", err)
+		}
+
+		debugHandler, err := debug.New(debug.Config{
+			Mutator: debugMutator,
+			Logger:  logger,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create debug handler: %w", err)
+		}
+		mux.Handle("/debug/mutate", debugHandler)
+
 		// Health checks.
 		mux.HandleFunc("/healthz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
 
@@ -156,14 +552,33 @@ func runApp() error {
 	{
 		logger := logger.WithKV(log.KV{"addr": cfg.WebhookListenAddr, "http-server": "webhooks"})
 
+		ingValModes := map[string]webhook.ValidationMode{}
+		for code, mode := range cfg.IngressValidatorModes {
+			ingValModes[code] = webhook.ValidationMode(mode)
+		}
+
 		// Webhook handler.
 		wh, err := webhook.New(webhook.Config{
-			Marker:                     marker,
-			IngressRegexHostValidator:  ingressHostValidator,
-			IngressSingleHostValidator: ingressSingleHostValidator,
-			ServiceMonitorSafer:        serviceMonitorSafer,
-			MetricsRecorder:            metricsRec,
-			Logger:                     logger,
+			Exempter:                       exempter,
+			Marker:                         marker,
+			IngressRegexHostValidator:      ingressHostValidator,
+			IngressSingleHostValidator:     ingressSingleHostValidator,
+			IngressHostPolicyValidator:     ingressHostPolicyValidator,
+			IngressNSHostPolicyValidator:   ingressNSHostPolicyValidator,
+			IngressHostUniquenessValidator: ingressHostUniquenessValidator,
+			IngressTLSValidator:            ingressTLSValidator,
+			IngressBackendValidator:        ingressBackendValidator,
+			IngressClassValidator:          ingressClassValidator,
+			IngressAnnotationValidator:     ingressAnnotationValidator,
+			IngressValidationAllErrors:     cfg.IngressAllErrors,
+			IngressValidatorModes:          ingValModes,
+			ServiceMonitorSafer:            serviceMonitorSafer,
+			PodMonitorSafer:                podMonitorSafer,
+			ProbeSafer:                     probeSafer,
+			ServiceMonitorValidator:        serviceMonitorValidator,
+			PrometheusRuleValidator:        prometheusRuleValidator,
+			MetricsRecorder:                metricsRec,
+			Logger:                         logger,
 		})
 		if err != nil {
 			return fmt.Errorf("could not create webhooks handler: %w", err)
@@ -173,16 +588,42 @@ func runApp() error {
 		mux.Handle("/", wh)
 		server := http.Server{Addr: cfg.WebhookListenAddr, Handler: mux}
 
+		tlsEnabled := cfg.TLSCertFilePath != "" && cfg.TLSKeyFilePath != ""
+		if tlsEnabled {
+			certReloader, err := certificate.NewFileReloader(certificate.FileReloaderConfig{
+				CertFilePath:    cfg.TLSCertFilePath,
+				KeyFilePath:     cfg.TLSKeyFilePath,
+				ReloadInterval:  cfg.TLSReloadInterval,
+				MetricsRecorder: metricsRec,
+				Logger:          logger,
+			})
+			if err != nil {
+				return fmt.Errorf("could not create TLS certificate reloader: %w", err)
+			}
+			server.TLSConfig = &tls.Config{GetCertificate: certReloader.GetCertificate}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			g.Add(
+				func() error {
+					return certReloader.Run(ctx)
+				},
+				func(_ error) {
+					cancel()
+				},
+			)
+		}
+
 		g.Add(
 			func() error {
-				if cfg.TLSCertFilePath == "" || cfg.TLSKeyFilePath == "" {
+				if !tlsEnabled {
 					logger.Warningf("webhook running without TLS")
 					logger.Infof("http server listening...")
 					return server.ListenAndServe()
 				}
 
+				// Cert and key are provided by the server TLS config, so they can be reloaded.
 				logger.Infof("https server listening...")
-				return server.ListenAndServeTLS(cfg.TLSCertFilePath, cfg.TLSKeyFilePath)
+				return server.ListenAndServeTLS("", "")
 			},
 			func(_ error) {
 				logger.Infof("start draining connections")
@@ -207,6 +648,45 @@ func runApp() error {
 	return nil
 }
 
+// usesNamespaceLabels returns true if any of the mark templates uses the namespace labels, so
+// the namespace cache is only created when required.
+func usesNamespaceLabels(marks map[string]string) bool {
+	for _, v := range marks {
+		if strings.Contains(v, ".NamespaceLabels") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newSaferConfig returns the safer configuration of a Prometheus operator monitoring resource kind,
+// the safer will be disabled (returns false) if none of the safety settings are set.
+func newSaferConfig(c SaferCmdConfig) (internalmutationprometheus.SaferConfig, bool) {
+	saferConfig := internalmutationprometheus.SaferConfig{
+		MinScrapeInterval:     c.MinScrapeInterval,
+		MinScrapeTimeout:      c.MinScrapeTimeout,
+		MaxScrapeTimeout:      c.MaxScrapeTimeout,
+		DefaultScrapeTimeout:  c.DefaultScrapeTimeout,
+		SampleLimit:           internalmutationprometheus.Limit{Default: c.DefaultSampleLimit, Max: c.MaxSampleLimit},
+		TargetLimit:           internalmutationprometheus.Limit{Default: c.DefaultTargetLimit, Max: c.MaxTargetLimit},
+		LabelLimit:            internalmutationprometheus.Limit{Default: c.DefaultLabelLimit, Max: c.MaxLabelLimit},
+		LabelNameLengthLimit:  internalmutationprometheus.Limit{Default: c.DefaultLabelNameLengthLimit, Max: c.MaxLabelNameLengthLimit},
+		LabelValueLengthLimit: internalmutationprometheus.Limit{Default: c.DefaultLabelValueLengthLimit, Max: c.MaxLabelValueLengthLimit},
+		Modes:                 map[internalmutationprometheus.SafetyRule]internalmutationprometheus.SafetyMode{},
+	}
+	for rule, mode := range c.SafetyModes {
+		saferConfig.Modes[internalmutationprometheus.SafetyRule(rule)] = internalmutationprometheus.SafetyMode(mode)
+	}
+
+	noLimit := internalmutationprometheus.Limit{}
+	enabled := c.MinScrapeInterval != 0 || c.MinScrapeTimeout != 0 || c.MaxScrapeTimeout != 0 || c.DefaultScrapeTimeout != 0 ||
+		saferConfig.SampleLimit != noLimit || saferConfig.TargetLimit != noLimit || saferConfig.LabelLimit != noLimit ||
+		saferConfig.LabelNameLengthLimit != noLimit || saferConfig.LabelValueLengthLimit != noLimit
+
+	return saferConfig, enabled
+}
+
 func main() {
 	err := runApp()
 	if err != nil {
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+// ingressNamespaceHostPolicyFile is the file format used to configure the ingress
+// host patterns per namespace. Example:
+//
+//	policies:
+//	  - namespaces: ["team-a", "team-a-staging"]
+//	    hostPatterns: ["*.team-a.example.com"]
+//	  - namespaceSelector: "tenant=team-b"
+//	    hostPatterns: [".team-b.example.com"]
+//	defaultHostPatterns: ["*.apps.example.com"]
+type ingressNamespaceHostPolicyFile struct {
+	Policies []struct {
+		Namespaces        []string `json:"namespaces,omitempty"`
+		NamespaceSelector string   `json:"namespaceSelector,omitempty"`
+		HostPatterns      []string `json:"hostPatterns,omitempty"`
+	} `json:"policies,omitempty"`
+	DefaultHostPatterns []string `json:"defaultHostPatterns,omitempty"`
+}
+
+func loadIngressNamespaceHostPolicies(path string) ([]ingress.NamespaceHostPolicy, []string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	f := ingressNamespaceHostPolicyFile{}
+	err = yaml.UnmarshalStrict(data, &f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not decode file: %w", err)
+	}
+
+	policies := make([]ingress.NamespaceHostPolicy, 0, len(f.Policies))
+	for _, p := range f.Policies {
+		policies = append(policies, ingress.NamespaceHostPolicy{
+			Namespaces:        p.Namespaces,
+			NamespaceSelector: p.NamespaceSelector,
+			HostPatterns:      p.HostPatterns,
+		})
+	}
+
+	return policies, f.DefaultHostPatterns, nil
+}
+
+// markRulesFile is the file format used to configure the marker rules. Example:
+//
+//	rules:
+//	  - resources:
+//	      - group: apps
+//	        kind: Deployment
+//	    namespaceSelector: "tenant=team-a"
+//	    objectSelector: "tier=frontend"
+//	    labels:
+//	      team: team-a
+//	    annotations:
+//	      owner: "{{ .Username }}"
+//	    modes:
+//	      team: reject-if-different
+type markRulesFile struct {
+	Rules []struct {
+		Resources []struct {
+			Group   string `json:"group,omitempty"`
+			Version string `json:"version,omitempty"`
+			Kind    string `json:"kind,omitempty"`
+		} `json:"resources,omitempty"`
+		NamespaceSelector string            `json:"namespaceSelector,omitempty"`
+		ObjectSelector    string            `json:"objectSelector,omitempty"`
+		Labels            map[string]string `json:"labels,omitempty"`
+		Annotations       map[string]string `json:"annotations,omitempty"`
+		Modes             map[string]string `json:"modes,omitempty"`
+	} `json:"rules,omitempty"`
+}
+
+func loadMarkRules(path string) ([]mark.Rule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	f := markRulesFile{}
+	err = yaml.UnmarshalStrict(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode file: %w", err)
+	}
+
+	rules := make([]mark.Rule, 0, len(f.Rules))
+	for _, r := range f.Rules {
+		resources := make([]mark.ResourceMatcher, 0, len(r.Resources))
+		for _, res := range r.Resources {
+			resources = append(resources, mark.ResourceMatcher{
+				Group:   res.Group,
+				Version: res.Version,
+				Kind:    res.Kind,
+			})
+		}
+
+		modes := map[string]mark.MarkMode{}
+		for k, m := range r.Modes {
+			modes[k] = mark.MarkMode(m)
+		}
+
+		rules = append(rules, mark.Rule{
+			Resources:         resources,
+			NamespaceSelector: r.NamespaceSelector,
+			ObjectSelector:    r.ObjectSelector,
+			Labels:            r.Labels,
+			Annotations:       r.Annotations,
+			Modes:             modes,
+		})
+	}
+
+	return rules, nil
+}
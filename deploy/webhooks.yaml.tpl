@@ -36,6 +36,36 @@ webhooks:
         apiVersions: ["v1"]
         resources: ["servicemonitors"]
 
+  - name: pod-monitor-safer.slok.dev
+    admissionReviewVersions: ["v1"]
+    sideEffects: None
+    clientConfig:
+      service:
+        name: k8s-webhook-example
+        namespace: k8s-webhook-example
+        path: /wh/mutating/safepodmonitor
+      caBundle: CA_BUNDLE
+    rules:
+      - operations: ["CREATE", "UPDATE"]
+        apiGroups: ["monitoring.coreos.com"]
+        apiVersions: ["v1"]
+        resources: ["podmonitors"]
+
+  - name: probe-safer.slok.dev
+    admissionReviewVersions: ["v1"]
+    sideEffects: None
+    clientConfig:
+      service:
+        name: k8s-webhook-example
+        namespace: k8s-webhook-example
+        path: /wh/mutating/safeprobe
+      caBundle: CA_BUNDLE
+    rules:
+      - operations: ["CREATE", "UPDATE"]
+        apiGroups: ["monitoring.coreos.com"]
+        apiVersions: ["v1"]
+        resources: ["probes"]
+
 ---
 apiVersion: admissionregistration.k8s.io/v1
 kind: ValidatingWebhookConfiguration
@@ -58,4 +88,79 @@ webhooks:
       - operations: ["CREATE", "UPDATE"]
         apiGroups: ["*"]
         apiVersions: ["*"]
-        resources: ["ingresses"]
\ No newline at end of file
+        resources: ["ingresses"]
+
+  - name: service-monitor-safer-validation.slok.dev
+    admissionReviewVersions: ["v1"]
+    sideEffects: None
+    clientConfig:
+      service:
+        name: k8s-webhook-example
+        namespace: k8s-webhook-example
+        path: /wh/validating/safeservicemonitor
+      caBundle: CA_BUNDLE
+    rules:
+      - operations: ["CREATE", "UPDATE"]
+        apiGroups: ["monitoring.coreos.com"]
+        apiVersions: ["v1"]
+        resources: ["servicemonitors"]
+
+  - name: pod-monitor-safer-validation.slok.dev
+    admissionReviewVersions: ["v1"]
+    sideEffects: None
+    clientConfig:
+      service:
+        name: k8s-webhook-example
+        namespace: k8s-webhook-example
+        path: /wh/validating/safepodmonitor
+      caBundle: CA_BUNDLE
+    rules:
+      - operations: ["CREATE", "UPDATE"]
+        apiGroups: ["monitoring.coreos.com"]
+        apiVersions: ["v1"]
+        resources: ["podmonitors"]
+
+  - name: probe-safer-validation.slok.dev
+    admissionReviewVersions: ["v1"]
+    sideEffects: None
+    clientConfig:
+      service:
+        name: k8s-webhook-example
+        namespace: k8s-webhook-example
+        path: /wh/validating/safeprobe
+      caBundle: CA_BUNDLE
+    rules:
+      - operations: ["CREATE", "UPDATE"]
+        apiGroups: ["monitoring.coreos.com"]
+        apiVersions: ["v1"]
+        resources: ["probes"]
+
+  - name: service-monitor-validation.slok.dev
+    admissionReviewVersions: ["v1"]
+    sideEffects: None
+    clientConfig:
+      service:
+        name: k8s-webhook-example
+        namespace: k8s-webhook-example
+        path: /wh/validating/servicemonitor
+      caBundle: CA_BUNDLE
+    rules:
+      - operations: ["CREATE", "UPDATE"]
+        apiGroups: ["monitoring.coreos.com"]
+        apiVersions: ["v1"]
+        resources: ["servicemonitors"]
+
+  - name: prometheus-rule-validation.slok.dev
+    admissionReviewVersions: ["v1"]
+    sideEffects: None
+    clientConfig:
+      service:
+        name: k8s-webhook-example
+        namespace: k8s-webhook-example
+        path: /wh/validating/prometheusrule
+      caBundle: CA_BUNDLE
+    rules:
+      - operations: ["CREATE", "UPDATE"]
+        apiGroups: ["monitoring.coreos.com"]
+        apiVersions: ["v1"]
+        resources: ["prometheusrules"]
\ No newline at end of file
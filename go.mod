@@ -1,16 +1,22 @@
 module github.com/slok/k8s-webhook-example
 
-go 1.15
+go 1.16
 
 require (
 	github.com/oklog/run v1.1.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring v0.51.0
-	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_golang v1.12.1
+	github.com/prometheus/common v0.34.0
+	github.com/prometheus/prometheus v0.35.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/slok/go-http-metrics v0.6.1
 	github.com/slok/kubewebhook/v2 v2.1.1-0.20210813062814-0d6b91199b6d
-	github.com/stretchr/testify v1.7.0
+	github.com/stretchr/testify v1.7.1
+	gomodules.xyz/jsonpatch/v3 v3.0.1
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
-	k8s.io/api v0.22.0
-	k8s.io/apimachinery v0.22.0
+	k8s.io/api v0.23.5
+	k8s.io/apimachinery v0.23.5
+	k8s.io/client-go v0.23.5
+	sigs.k8s.io/yaml v1.2.0
 )
@@ -0,0 +1,173 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/slok/k8s-webhook-example/internal/log"
+)
+
+// MetricsRecorder knows how to record TLS certificate metrics.
+type MetricsRecorder interface {
+	SetTLSCertificateExpiry(ctx context.Context, expiry time.Time)
+}
+
+// DummyMetricsRecorder is a MetricsRecorder that doesn't do anything.
+const DummyMetricsRecorder = dummyMetricsRecorder(0)
+
+type dummyMetricsRecorder int
+
+var _ MetricsRecorder = DummyMetricsRecorder
+
+func (dummyMetricsRecorder) SetTLSCertificateExpiry(_ context.Context, _ time.Time) {}
+
+// FileReloaderConfig is the FileReloader configuration.
+type FileReloaderConfig struct {
+	CertFilePath    string
+	KeyFilePath     string
+	ReloadInterval  time.Duration
+	MetricsRecorder MetricsRecorder
+	Logger          log.Logger
+}
+
+func (c *FileReloaderConfig) defaults() error {
+	if c.CertFilePath == "" {
+		return fmt.Errorf("cert file path is required")
+	}
+
+	if c.KeyFilePath == "" {
+		return fmt.Errorf("key file path is required")
+	}
+
+	if c.ReloadInterval <= 0 {
+		c.ReloadInterval = 30 * time.Second
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = DummyMetricsRecorder
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Dummy
+	}
+
+	return nil
+}
+
+// FileReloader provides a TLS certificate loaded from cert and key files, and reloads
+// it when the files change (e.g: cert-manager rotating the mounted secret).
+// If the new cert/key pair is not valid it will keep serving the previous one.
+type FileReloader struct {
+	certFilePath   string
+	keyFilePath    string
+	reloadInterval time.Duration
+	metrics        MetricsRecorder
+	logger         log.Logger
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+
+	// Last read files content, used to detect changes.
+	lastCertPEM []byte
+	lastKeyPEM  []byte
+}
+
+// NewFileReloader returns a new FileReloader with the certificate already loaded.
+func NewFileReloader(config FileReloaderConfig) (*FileReloader, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	f := &FileReloader{
+		certFilePath:   config.CertFilePath,
+		keyFilePath:    config.KeyFilePath,
+		reloadInterval: config.ReloadInterval,
+		metrics:        config.MetricsRecorder,
+		logger:         config.Logger.WithKV(log.KV{"service": "tls-certificate-reloader"}),
+	}
+
+	_, err = f.reload(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not load TLS certificate: %w", err)
+	}
+
+	return f, nil
+}
+
+// GetCertificate returns the current certificate, it satisfies `tls.Config.GetCertificate`.
+func (f *FileReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.cert, nil
+}
+
+// Run will check periodically the cert and key files, reloading the certificate when these change.
+// It will block until the context is done.
+func (f *FileReloader) Run(ctx context.Context) error {
+	t := time.NewTicker(f.reloadInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			reloaded, err := f.reload(ctx)
+			if err != nil {
+				f.logger.Errorf("could not reload TLS certificate, keeping the previous one: %s", err)
+				continue
+			}
+
+			if reloaded {
+				f.logger.Infof("TLS certificate reloaded")
+			}
+		}
+	}
+}
+
+// reload loads the certificate if the files have changed since the last time they were read.
+func (f *FileReloader) reload(ctx context.Context) (bool, error) {
+	certPEM, err := ioutil.ReadFile(f.certFilePath)
+	if err != nil {
+		return false, fmt.Errorf("could not read cert file: %w", err)
+	}
+
+	keyPEM, err := ioutil.ReadFile(f.keyFilePath)
+	if err != nil {
+		return false, fmt.Errorf("could not read key file: %w", err)
+	}
+
+	// Nothing changed since the last try, don't reload (valid or not).
+	if bytes.Equal(certPEM, f.lastCertPEM) && bytes.Equal(keyPEM, f.lastKeyPEM) {
+		return false, nil
+	}
+	f.lastCertPEM = certPEM
+	f.lastKeyPEM = keyPEM
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("invalid cert/key pair: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return false, fmt.Errorf("could not parse certificate: %w", err)
+	}
+	cert.Leaf = leaf
+
+	f.mu.Lock()
+	f.cert = &cert
+	f.mu.Unlock()
+
+	f.metrics.SetTLSCertificateExpiry(ctx, leaf.NotAfter)
+
+	return true, nil
+}
@@ -0,0 +1,159 @@
+package certificate_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/k8s-webhook-example/internal/certificate"
+)
+
+type testMetricsRecorder struct {
+	mu     sync.Mutex
+	expiry time.Time
+}
+
+func (t *testMetricsRecorder) SetTLSCertificateExpiry(_ context.Context, expiry time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expiry = expiry
+}
+
+func (t *testMetricsRecorder) Expiry() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.expiry
+}
+
+func genCertKeyPEM(t *testing.T, notAfter time.Time) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test.slok.dev"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+func writeFiles(t *testing.T, certPath, keyPath string, certPEM, keyPEM []byte) {
+	require.NoError(t, ioutil.WriteFile(certPath, certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(keyPath, keyPEM, 0600))
+}
+
+func getCertExpiry(t *testing.T, r *certificate.FileReloader) time.Time {
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	return cert.Leaf.NotAfter
+}
+
+func TestFileReloader(t *testing.T) {
+	expiry1 := time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+	expiry2 := time.Now().Add(48 * time.Hour).Truncate(time.Second).UTC()
+
+	tests := map[string]struct {
+		update    func(t *testing.T, certPath, keyPath string)
+		expExpiry time.Time
+	}{
+		"Not changing the files should keep the loaded certificate.": {
+			update:    func(t *testing.T, certPath, keyPath string) {},
+			expExpiry: expiry1,
+		},
+
+		"Changing the files with a valid cert/key pair should reload the certificate.": {
+			update: func(t *testing.T, certPath, keyPath string) {
+				cert, key := genCertKeyPEM(t, expiry2)
+				writeFiles(t, certPath, keyPath, cert, key)
+			},
+			expExpiry: expiry2,
+		},
+
+		"Changing the files with an invalid cert/key pair should keep the previous certificate.": {
+			update: func(t *testing.T, certPath, keyPath string) {
+				writeFiles(t, certPath, keyPath, []byte("wrong"), []byte("wrong"))
+			},
+			expExpiry: expiry1,
+		},
+
+		"Changing the files with a cert and key that don't match should keep the previous certificate.": {
+			update: func(t *testing.T, certPath, keyPath string) {
+				cert, _ := genCertKeyPEM(t, expiry2)
+				_, key := genCertKeyPEM(t, expiry2)
+				writeFiles(t, certPath, keyPath, cert, key)
+			},
+			expExpiry: expiry1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			dir := t.TempDir()
+			certPath := filepath.Join(dir, "cert.pem")
+			keyPath := filepath.Join(dir, "key.pem")
+			cert, key := genCertKeyPEM(t, expiry1)
+			writeFiles(t, certPath, keyPath, cert, key)
+
+			mrec := &testMetricsRecorder{}
+			r, err := certificate.NewFileReloader(certificate.FileReloaderConfig{
+				CertFilePath:    certPath,
+				KeyFilePath:     keyPath,
+				ReloadInterval:  5 * time.Millisecond,
+				MetricsRecorder: mrec,
+			})
+			require.NoError(err)
+			assert.Equal(expiry1, getCertExpiry(t, r))
+			assert.Equal(expiry1, mrec.Expiry())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { _ = r.Run(ctx) }()
+
+			test.update(t, certPath, keyPath)
+
+			// Wait some reload loops.
+			time.Sleep(50 * time.Millisecond)
+			assert.Equal(test.expExpiry, getCertExpiry(t, r))
+			assert.Equal(test.expExpiry, mrec.Expiry())
+		})
+	}
+}
+
+func TestFileReloaderInvalidInitialCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	writeFiles(t, certPath, keyPath, []byte("wrong"), []byte("wrong"))
+
+	_, err := certificate.NewFileReloader(certificate.FileReloaderConfig{
+		CertFilePath: certPath,
+		KeyFilePath:  keyPath,
+	})
+	assert.Error(t, err)
+}
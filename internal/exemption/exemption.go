@@ -0,0 +1,190 @@
+package exemption
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// SkipAnnotation is the annotation that objects can use to opt-out from the webhooks.
+const SkipAnnotation = "k8s-webhook-example.slok.dev/skip"
+
+// Reason is the reason an object has been exempted.
+type Reason string
+
+const (
+	// ReasonNamespace is used when an object is exempted by its namespace name.
+	ReasonNamespace Reason = "namespace"
+	// ReasonNamespaceSelector is used when an object is exempted by its namespace labels.
+	ReasonNamespaceSelector Reason = "namespace-selector"
+	// ReasonObjectSelector is used when an object is exempted by its labels.
+	ReasonObjectSelector Reason = "object-selector"
+	// ReasonAnnotation is used when an object is exempted by the skip annotation.
+	ReasonAnnotation Reason = "annotation"
+	// ReasonUser is used when an object is exempted by the requesting user.
+	ReasonUser Reason = "user"
+	// ReasonGroup is used when an object is exempted by the requesting user groups.
+	ReasonGroup Reason = "group"
+)
+
+// Request is the information used to check if an object is exempted.
+type Request struct {
+	// Namespace is the namespace of the object, empty on cluster scoped objects.
+	Namespace string
+	// Object is the object being admitted.
+	Object metav1.Object
+	// Username is the name of the user that requested the admission.
+	Username string
+	// Groups are the groups of the user that requested the admission.
+	Groups []string
+}
+
+// Result is the result of an exemption check.
+type Result struct {
+	Exempted bool
+	Reason   Reason
+}
+
+// Exempter knows if an object should be skipped by the webhooks.
+type Exempter interface {
+	Exempt(ctx context.Context, r Request) (*Result, error)
+}
+
+// NamespaceLabelsGetter knows how to get the labels of a namespace.
+type NamespaceLabelsGetter interface {
+	GetNamespaceLabels(ctx context.Context, namespace string) (map[string]string, error)
+}
+
+// Config is the exempter configuration.
+type Config struct {
+	// Namespaces are the namespace names whose objects will be exempted.
+	Namespaces []string
+	// NamespaceSelector is a Kubernetes label selector (e.g `webhooks=disabled`) that will
+	// exempt the objects of the namespaces matching it.
+	NamespaceSelector string
+	// ObjectSelector is a Kubernetes label selector that will exempt the objects matching it.
+	ObjectSelector string
+	// EnableSkipAnnotation will exempt the objects that have `SkipAnnotation` set to `true`.
+	EnableSkipAnnotation bool
+	// Usernames are the requesting usernames whose objects will be exempted.
+	Usernames []string
+	// Groups are the requesting user groups whose objects will be exempted.
+	Groups []string
+	// NamespaceLabelsGetter is used to get the namespace labels when using a namespace selector.
+	NamespaceLabelsGetter NamespaceLabelsGetter
+}
+
+func (c *Config) defaults() error {
+	if c.NamespaceSelector != "" && c.NamespaceLabelsGetter == nil {
+		return fmt.Errorf("namespace labels getter is required when using a namespace selector")
+	}
+
+	return nil
+}
+
+// NewExempter returns a new exempter that will exempt objects based on the object namespace
+// (by name or labels), the object labels, the skip annotation and the requesting user and groups.
+func NewExempter(config Config) (Exempter, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	e := exempter{
+		namespaces:     toSet(config.Namespaces),
+		skipAnnotation: config.EnableSkipAnnotation,
+		usernames:      toSet(config.Usernames),
+		groups:         toSet(config.Groups),
+		nsLabelsGetter: config.NamespaceLabelsGetter,
+	}
+
+	if config.NamespaceSelector != "" {
+		e.nsSelector, err = labels.Parse(config.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("namespace selector is not valid: %w", err)
+		}
+	}
+
+	if config.ObjectSelector != "" {
+		e.objSelector, err = labels.Parse(config.ObjectSelector)
+		if err != nil {
+			return nil, fmt.Errorf("object selector is not valid: %w", err)
+		}
+	}
+
+	return e, nil
+}
+
+type exempter struct {
+	namespaces     map[string]struct{}
+	nsSelector     labels.Selector
+	objSelector    labels.Selector
+	skipAnnotation bool
+	usernames      map[string]struct{}
+	groups         map[string]struct{}
+	nsLabelsGetter NamespaceLabelsGetter
+}
+
+func (e exempter) Exempt(ctx context.Context, r Request) (*Result, error) {
+	if _, ok := e.usernames[r.Username]; ok && r.Username != "" {
+		return &Result{Exempted: true, Reason: ReasonUser}, nil
+	}
+
+	for _, g := range r.Groups {
+		if _, ok := e.groups[g]; ok {
+			return &Result{Exempted: true, Reason: ReasonGroup}, nil
+		}
+	}
+
+	if r.Object != nil {
+		if e.skipAnnotation && r.Object.GetAnnotations()[SkipAnnotation] == "true" {
+			return &Result{Exempted: true, Reason: ReasonAnnotation}, nil
+		}
+
+		if e.objSelector != nil && e.objSelector.Matches(labels.Set(r.Object.GetLabels())) {
+			return &Result{Exempted: true, Reason: ReasonObjectSelector}, nil
+		}
+	}
+
+	// Cluster scoped objects don't have namespace.
+	if r.Namespace == "" {
+		return &Result{Exempted: false}, nil
+	}
+
+	if _, ok := e.namespaces[r.Namespace]; ok {
+		return &Result{Exempted: true, Reason: ReasonNamespace}, nil
+	}
+
+	if e.nsSelector != nil {
+		nsLabels, err := e.nsLabelsGetter.GetNamespaceLabels(ctx, r.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("could not get namespace %q labels: %w", r.Namespace, err)
+		}
+
+		if e.nsSelector.Matches(labels.Set(nsLabels)) {
+			return &Result{Exempted: true, Reason: ReasonNamespaceSelector}, nil
+		}
+	}
+
+	return &Result{Exempted: false}, nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+
+	return set
+}
+
+// DummyExempter is an exempter that doesn't exempt any object.
+var DummyExempter Exempter = dummyExempter(0)
+
+type dummyExempter int
+
+func (dummyExempter) Exempt(_ context.Context, _ Request) (*Result, error) {
+	return &Result{Exempted: false}, nil
+}
@@ -0,0 +1,173 @@
+package exemption_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/exemption"
+)
+
+type testNamespaceLabelsGetter map[string]map[string]string
+
+func (t testNamespaceLabelsGetter) GetNamespaceLabels(_ context.Context, namespace string) (map[string]string, error) {
+	l, ok := t[namespace]
+	if !ok {
+		return nil, fmt.Errorf("namespace missing")
+	}
+	return l, nil
+}
+
+func newPod(labels, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestExempterExempt(t *testing.T) {
+	nsGetter := testNamespaceLabelsGetter{
+		"default":     {},
+		"kube-system": {"webhooks": "disabled"},
+	}
+
+	config := exemption.Config{
+		Namespaces:            []string{"monitoring"},
+		NamespaceSelector:     "webhooks=disabled",
+		ObjectSelector:        "app=legacy",
+		EnableSkipAnnotation:  true,
+		Usernames:             []string{"system:serviceaccount:flux:flux"},
+		Groups:                []string{"system:masters"},
+		NamespaceLabelsGetter: nsGetter,
+	}
+
+	tests := map[string]struct {
+		config    exemption.Config
+		request   exemption.Request
+		expResult *exemption.Result
+		expErr    bool
+	}{
+		"Without exemptions, it should not exempt.": {
+			config:    exemption.Config{},
+			request:   exemption.Request{Namespace: "monitoring", Object: newPod(nil, nil)},
+			expResult: &exemption.Result{Exempted: false},
+		},
+
+		"An object not matching any exemption, it should not exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "default", Object: newPod(nil, nil), Username: "test", Groups: []string{"test"}},
+			expResult: &exemption.Result{Exempted: false},
+		},
+
+		"An object on an exempted namespace, it should exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "monitoring", Object: newPod(nil, nil)},
+			expResult: &exemption.Result{Exempted: true, Reason: exemption.ReasonNamespace},
+		},
+
+		"An object on a namespace matching the namespace selector, it should exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "kube-system", Object: newPod(nil, nil)},
+			expResult: &exemption.Result{Exempted: true, Reason: exemption.ReasonNamespaceSelector},
+		},
+
+		"An object matching the object selector, it should exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "default", Object: newPod(map[string]string{"app": "legacy"}, nil)},
+			expResult: &exemption.Result{Exempted: true, Reason: exemption.ReasonObjectSelector},
+		},
+
+		"An object with the skip annotation, it should exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "default", Object: newPod(nil, map[string]string{exemption.SkipAnnotation: "true"})},
+			expResult: &exemption.Result{Exempted: true, Reason: exemption.ReasonAnnotation},
+		},
+
+		"An object with the skip annotation not being true, it should not exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "default", Object: newPod(nil, map[string]string{exemption.SkipAnnotation: "false"})},
+			expResult: &exemption.Result{Exempted: false},
+		},
+
+		"An object with the skip annotation and the skip annotation disabled, it should not exempt.": {
+			config:    exemption.Config{},
+			request:   exemption.Request{Namespace: "default", Object: newPod(nil, map[string]string{exemption.SkipAnnotation: "true"})},
+			expResult: &exemption.Result{Exempted: false},
+		},
+
+		"An object requested by an exempted user, it should exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "default", Object: newPod(nil, nil), Username: "system:serviceaccount:flux:flux"},
+			expResult: &exemption.Result{Exempted: true, Reason: exemption.ReasonUser},
+		},
+
+		"An object requested by a user of an exempted group, it should exempt.": {
+			config:    config,
+			request:   exemption.Request{Namespace: "default", Object: newPod(nil, nil), Username: "test", Groups: []string{"system:authenticated", "system:masters"}},
+			expResult: &exemption.Result{Exempted: true, Reason: exemption.ReasonGroup},
+		},
+
+		"A cluster scoped object not matching any exemption, it should not exempt.": {
+			config:    config,
+			request:   exemption.Request{Object: newPod(nil, nil)},
+			expResult: &exemption.Result{Exempted: false},
+		},
+
+		"An object on a missing namespace using namespace selector, it should fail.": {
+			config:  config,
+			request: exemption.Request{Namespace: "missing", Object: newPod(nil, nil)},
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			e, err := exemption.NewExempter(test.config)
+			require.NoError(err)
+
+			gotResult, err := e.Exempt(context.TODO(), test.request)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expResult, gotResult)
+			}
+		})
+	}
+}
+
+func TestNewExempterInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		config exemption.Config
+	}{
+		"A namespace selector without namespace labels getter should be invalid.": {
+			config: exemption.Config{NamespaceSelector: "webhooks=disabled"},
+		},
+
+		"An invalid namespace selector should be invalid.": {
+			config: exemption.Config{NamespaceSelector: "webhooks in (", NamespaceLabelsGetter: testNamespaceLabelsGetter{}},
+		},
+
+		"An invalid object selector should be invalid.": {
+			config: exemption.Config{ObjectSelector: "app in ("},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := exemption.NewExempter(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
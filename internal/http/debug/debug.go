@@ -0,0 +1,304 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pmezard/go-difflib/difflib"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	kwhmodel "github.com/slok/kubewebhook/v2/pkg/model"
+	"gomodules.xyz/jsonpatch/v3"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	"github.com/slok/k8s-webhook-example/internal/http/webhook"
+	"github.com/slok/k8s-webhook-example/internal/log"
+)
+
+// Config is the debug handler configuration.
+type Config struct {
+	// Mutator is the mutator used by the webhooks.
+	Mutator webhook.Mutator
+	Logger  log.Logger
+}
+
+func (c *Config) defaults() error {
+	if c.Mutator == nil {
+		return fmt.Errorf("mutator is required")
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Dummy
+	}
+
+	return nil
+}
+
+// New returns a new debug handler that runs the received objects through the same mutator the
+// webhooks use (exemptions included) and returns the mutation result, this is useful to check
+// the webhooks configuration without a Kubernetes cluster.
+//
+// It accepts in JSON or YAML a Kubernetes `AdmissionReview` (`CREATE` and `UPDATE` operations, the
+// old object and the dry run are passed to the mutator like the webhooks do) or a plain Kubernetes
+// object, that will be mutated as a creation.
+func New(config Config) (http.Handler, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("handler configuration is not valid: %w", err)
+	}
+
+	return handler{
+		mutator: config.Mutator,
+		logger:  config.Logger.WithKV(log.KV{"service": "debug-handler"}),
+	}, nil
+}
+
+type handler struct {
+	mutator webhook.Mutator
+	logger  log.Logger
+}
+
+// mutationResponse is the response of the debug mutations.
+type mutationResponse struct {
+	// Allowed is false when the mutators deny the object.
+	Allowed bool `json:"allowed"`
+	// Message is the denial message.
+	Message  string                         `json:"message,omitempty"`
+	Warnings []string                       `json:"warnings,omitempty"`
+	Patch    []jsonpatch.JsonPatchOperation `json:"patch"`
+	// Diff is a human readable unified diff in YAML of the original and the mutated objects.
+	Diff string `json:"diff"`
+}
+
+// mutationRequest is the information of the object to mutate.
+type mutationRequest struct {
+	raw       []byte
+	oldRaw    []byte
+	operation kwhmodel.AdmissionReviewOp
+	dryRun    bool
+	namespace string
+	username  string
+	groups    []string
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not read body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	req, err := newMutationRequest(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.mutate(r.Context(), *req)
+	if err != nil {
+		h.logger.Errorf("could not mutate object: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		h.logger.Errorf("could not write response: %s", err)
+	}
+}
+
+// newMutationRequest gets the object from the received data, that can be an admission
+// review or a plain object in JSON or YAML.
+func newMutationRequest(data []byte) (*mutationRequest, error) {
+	raw, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode body: %w", err)
+	}
+
+	tm := metav1.TypeMeta{}
+	err = json.Unmarshal(raw, &tm)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode body: %w", err)
+	}
+
+	if tm.Kind != "AdmissionReview" {
+		return &mutationRequest{raw: raw, operation: kwhmodel.OperationCreate}, nil
+	}
+
+	// The request of `v1` and `v1beta1` admission reviews have the same fields.
+	ar := admissionv1.AdmissionReview{}
+	err = json.Unmarshal(raw, &ar)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode admission review: %w", err)
+	}
+
+	if ar.Request == nil || len(ar.Request.Object.Raw) == 0 {
+		return nil, fmt.Errorf("admission review request object is missing")
+	}
+
+	// The admission reviews without operation (e.g: handwritten) are mutated as a creation.
+	var operation kwhmodel.AdmissionReviewOp
+	switch ar.Request.Operation {
+	case "", admissionv1.Create:
+		operation = kwhmodel.OperationCreate
+	case admissionv1.Update:
+		operation = kwhmodel.OperationUpdate
+	default:
+		return nil, fmt.Errorf("admission review request operation %q is not supported", ar.Request.Operation)
+	}
+
+	return &mutationRequest{
+		raw:       ar.Request.Object.Raw,
+		oldRaw:    ar.Request.OldObject.Raw,
+		operation: operation,
+		dryRun:    ar.Request.DryRun != nil && *ar.Request.DryRun,
+		namespace: ar.Request.Namespace,
+		username:  ar.Request.UserInfo.Username,
+		groups:    ar.Request.UserInfo.Groups,
+	}, nil
+}
+
+func (h handler) mutate(ctx context.Context, req mutationRequest) (*mutationResponse, error) {
+	obj, err := newObject(req.raw)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := req.namespace
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+
+	// Like the API server, set the request namespace on the objects without it before the admission.
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(namespace)
+	}
+
+	// The decoded object is used as the original (instead of the received data), so the patch only
+	// has the mutations and not the decoding changes (e.g: `creationTimestamp: null`).
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode object: %w", err)
+	}
+
+	ar := &kwhmodel.AdmissionReview{
+		Name:         obj.GetName(),
+		Namespace:    namespace,
+		Operation:    req.operation,
+		OldObjectRaw: req.oldRaw,
+		NewObjectRaw: req.raw,
+		DryRun:       req.dryRun,
+		UserInfo: authenticationv1.UserInfo{
+			Username: req.username,
+			Groups:   req.groups,
+		},
+	}
+
+	res, err := h.mutator.Mutate(ctx, ar, obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not mutate the object: %w", err)
+	}
+
+	resp := &mutationResponse{
+		Allowed:  res.Allowed,
+		Message:  res.Message,
+		Warnings: res.Warnings,
+	}
+	if !resp.Allowed {
+		return resp, nil
+	}
+
+	mutated, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode mutated object: %w", err)
+	}
+
+	resp.Patch, err = jsonpatch.CreatePatch(original, mutated)
+	if err != nil {
+		return nil, fmt.Errorf("could not create JSON patch: %w", err)
+	}
+
+	resp.Diff, err = yamlDiff(original, mutated)
+	if err != nil {
+		return nil, fmt.Errorf("could not create diff: %w", err)
+	}
+
+	return resp, nil
+}
+
+// newObject returns a Kubernetes object from raw JSON data, the Prometheus operator monitoring
+// resources will be typed like the static webhooks, the rest of the known types will be typed
+// objects and the unknown ones will fallback to unstructured, like the dynamic webhooks.
+func newObject(raw []byte) (metav1.Object, error) {
+	tm := metav1.TypeMeta{}
+	err := json.Unmarshal(raw, &tm)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode object: %w", err)
+	}
+
+	var typed metav1.Object
+	switch tm.GroupVersionKind().GroupKind() {
+	case monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.ServiceMonitorsKind).GroupKind():
+		typed = &monitoringv1.ServiceMonitor{}
+	case monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.PodMonitorsKind).GroupKind():
+		typed = &monitoringv1.PodMonitor{}
+	case monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.ProbesKind).GroupKind():
+		typed = &monitoringv1.Probe{}
+	}
+	if typed != nil {
+		err := json.Unmarshal(raw, typed)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode %s: %w", tm.Kind, err)
+		}
+		return typed, nil
+	}
+
+	robj, _, err := clientsetscheme.Codecs.UniversalDeserializer().Decode(raw, nil, nil)
+	if err == nil {
+		if obj, ok := robj.(metav1.Object); ok {
+			return obj, nil
+		}
+	}
+
+	obj := &unstructured.Unstructured{}
+	err = obj.UnmarshalJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode object: %w", err)
+	}
+
+	return obj, nil
+}
+
+// yamlDiff returns a unified diff in YAML of the JSON objects.
+func yamlDiff(original, mutated []byte) (string, error) {
+	a, err := yaml.JSONToYAML(original)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := yaml.JSONToYAML(mutated)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "original",
+		ToFile:   "mutated",
+		Context:  3,
+	})
+}
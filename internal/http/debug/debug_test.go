@@ -0,0 +1,520 @@
+package debug_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kwhmodel "github.com/slok/kubewebhook/v2/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/exemption"
+	"github.com/slok/k8s-webhook-example/internal/http/debug"
+	"github.com/slok/k8s-webhook-example/internal/http/webhook"
+	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
+	"github.com/slok/k8s-webhook-example/internal/mutation/prometheus"
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+type testPatchOperation struct {
+	Operation string      `json:"op"`
+	Path      string      `json:"path"`
+	Value     interface{} `json:"value"`
+}
+
+type testResponse struct {
+	Allowed  bool                 `json:"allowed"`
+	Message  string               `json:"message"`
+	Warnings []string             `json:"warnings"`
+	Patch    []testPatchOperation `json:"patch"`
+	Diff     string               `json:"diff"`
+}
+
+// newTestMutatorConfig returns the configuration of the mutator used by the tests, shared
+// by the debug handler and the webhooks.
+func newTestMutatorConfig(t *testing.T) webhook.MutatorConfig {
+	require := require.New(t)
+
+	exempter, err := exemption.NewExempter(exemption.Config{Namespaces: []string{"kube-system"}})
+	require.NoError(err)
+
+	marker, err := mark.NewLabelMarker(mark.MarkerConfig{
+		Marks: map[string]string{"team": "team-a", "user": "{{ .Username }}"},
+		Modes: map[string]mark.MarkMode{"team": mark.MarkModeRejectIfDifferent},
+	})
+	require.NoError(err)
+
+	safer, err := prometheus.NewServiceMonitorSafer(prometheus.SaferConfig{MinScrapeInterval: 30 * time.Second})
+	require.NoError(err)
+	podMonSafer, err := prometheus.NewPodMonitorSafer(prometheus.SaferConfig{
+		MinScrapeInterval: 30 * time.Second,
+		Modes:             map[prometheus.SafetyRule]prometheus.SafetyMode{prometheus.SafetyRuleScrapeInterval: prometheus.SafetyModeValidate},
+	})
+	require.NoError(err)
+	probeSafer, err := prometheus.NewProbeSafer(prometheus.SaferConfig{MinScrapeInterval: 30 * time.Second})
+	require.NoError(err)
+
+	return webhook.MutatorConfig{
+		Exempter:            exempter,
+		Marker:              marker,
+		ServiceMonitorSafer: safer,
+		PodMonitorSafer:     podMonSafer,
+		ProbeSafer:          probeSafer,
+	}
+}
+
+func newTestMutator(t *testing.T) webhook.Mutator {
+	m, err := webhook.NewMutator(newTestMutatorConfig(t))
+	require.NoError(t, err)
+	return m
+}
+
+func TestHandler(t *testing.T) {
+	tests := map[string]struct {
+		method      string
+		body        string
+		expCode     int
+		expAllowed  bool
+		expMessage  string
+		expWarnings []string
+		expPatch    []testPatchOperation
+		expDiff     []string
+	}{
+		"A non POST request should fail.": {
+			method:  http.MethodGet,
+			expCode: http.StatusMethodNotAllowed,
+		},
+
+		"An invalid body should fail.": {
+			method:  http.MethodPost,
+			body:    `{"kind": `,
+			expCode: http.StatusBadRequest,
+		},
+
+		"An admission review without object should fail.": {
+			method:  http.MethodPost,
+			body:    `{"kind": "AdmissionReview", "apiVersion": "admission.k8s.io/v1", "request": {}}`,
+			expCode: http.StatusBadRequest,
+		},
+
+		"An admission review with a not supported operation should fail.": {
+			method:  http.MethodPost,
+			body:    `{"kind": "AdmissionReview", "apiVersion": "admission.k8s.io/v1", "request": {"operation": "DELETE", "object": {"kind": "ConfigMap", "apiVersion": "v1"}}}`,
+			expCode: http.StatusBadRequest,
+		},
+
+		"A plain object in YAML should be marked.": {
+			method: http.MethodPost,
+			body: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: test-ns
+`,
+			expCode:    http.StatusOK,
+			expAllowed: true,
+			expPatch: []testPatchOperation{
+				{Operation: "add", Path: "/metadata/labels", Value: map[string]interface{}{"team": "team-a", "user": ""}},
+			},
+			expDiff:     []string{"+  labels:", "+    team: team-a", "+    user: \"\""},
+			expWarnings: []string{"Resource marked with custom labels"},
+		},
+
+		"An admission review should be marked with the request information.": {
+			method: http.MethodPost,
+			body: `{
+  "kind": "AdmissionReview",
+  "apiVersion": "admission.k8s.io/v1",
+  "request": {
+    "uid": "test",
+    "namespace": "test-ns",
+    "userInfo": {"username": "alice"},
+    "object": {"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "labels": {"app": "test"}}}
+  }
+}`,
+			expCode:    http.StatusOK,
+			expAllowed: true,
+			expPatch: []testPatchOperation{
+				{Operation: "add", Path: "/metadata/labels/team", Value: "team-a"},
+				{Operation: "add", Path: "/metadata/labels/user", Value: "alice"},
+			},
+			expDiff:     []string{"+    team: team-a", "+    user: alice"},
+			expWarnings: []string{"Resource marked with custom labels"},
+		},
+
+		"An object rejected by the marker should be denied.": {
+			method:     http.MethodPost,
+			body:       `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "labels": {"team": "team-b"}}}`,
+			expCode:    http.StatusOK,
+			expAllowed: false,
+			expMessage: "resource marks are invalid",
+		},
+
+		"A service monitor should be mutated with the safer.": {
+			method: http.MethodPost,
+			body: `{
+  "kind": "ServiceMonitor",
+  "apiVersion": "monitoring.coreos.com/v1",
+  "metadata": {"name": "test", "namespace": "test-ns"},
+  "spec": {"endpoints": [{"interval": "1s"}], "selector": {}}
+}`,
+			expCode:    http.StatusOK,
+			expAllowed: true,
+			expPatch: []testPatchOperation{
+				{Operation: "replace", Path: "/spec/endpoints/0/interval", Value: "30s"},
+			},
+			expDiff:     []string{"-    interval: 1s", "+    interval: 30s"},
+			expWarnings: []string{`endpoint 0 interval "1s" has been changed to "30s"`},
+		},
+
+		"A probe should be mutated with the safer.": {
+			method: http.MethodPost,
+			body: `
+apiVersion: monitoring.coreos.com/v1
+kind: Probe
+metadata:
+  name: test
+spec:
+  interval: 5s
+`,
+			expCode:    http.StatusOK,
+			expAllowed: true,
+			expPatch: []testPatchOperation{
+				{Operation: "replace", Path: "/spec/interval", Value: "30s"},
+			},
+			expDiff:     []string{"-  interval: 5s", "+  interval: 30s"},
+			expWarnings: []string{`interval "5s" has been changed to "30s"`},
+		},
+
+		"A pod monitor with unsafe settings in validate mode should be denied.": {
+			method: http.MethodPost,
+			body: `
+apiVersion: monitoring.coreos.com/v1
+kind: PodMonitor
+metadata:
+  name: test
+spec:
+  podMetricsEndpoints:
+  - interval: 5s
+    scrapeTimeout: 5s
+  selector: {}
+`,
+			expCode:    http.StatusOK,
+			expAllowed: false,
+			expMessage: `pod monitor is not safe: endpoint 0 interval "5s" is not safe, expected "30s"`,
+		},
+
+		"An exempted object should not be mutated.": {
+			method:     http.MethodPost,
+			body:       `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "kube-system"}}`,
+			expCode:    http.StatusOK,
+			expAllowed: true,
+			expPatch:   []testPatchOperation{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			h, err := debug.New(debug.Config{Mutator: newTestMutator(t)})
+			require.NoError(err)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(test.method, "/debug/mutate", strings.NewReader(test.body))
+			h.ServeHTTP(w, r)
+
+			require.Equal(test.expCode, w.Code)
+			if test.expCode != http.StatusOK {
+				return
+			}
+
+			resp := testResponse{}
+			err = json.Unmarshal(w.Body.Bytes(), &resp)
+			require.NoError(err)
+
+			assert.Equal(test.expAllowed, resp.Allowed)
+			assert.Contains(resp.Message, test.expMessage)
+			assert.Equal(test.expWarnings, resp.Warnings)
+
+			assert.Equal(test.expPatch, resp.Patch)
+
+			for _, d := range test.expDiff {
+				assert.Contains(resp.Diff, d)
+			}
+		})
+	}
+}
+
+// testRecorderMutator is a mutator that records the received admission review.
+type testRecorderMutator struct {
+	ar *kwhmodel.AdmissionReview
+}
+
+func (t *testRecorderMutator) Mutate(_ context.Context, ar *kwhmodel.AdmissionReview, _ metav1.Object) (*webhook.MutationResult, error) {
+	t.ar = ar
+	return &webhook.MutationResult{Allowed: true}, nil
+}
+
+func TestHandlerAdmissionReview(t *testing.T) {
+	oldObject := `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "test-ns", "labels": {"team": "team-b"}}}`
+	object := `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "test-ns"}}`
+
+	tests := map[string]struct {
+		body  string
+		expAR *kwhmodel.AdmissionReview
+	}{
+		"A plain object should be mutated as a creation.": {
+			body: object,
+			expAR: &kwhmodel.AdmissionReview{
+				Name:         "test",
+				Namespace:    "test-ns",
+				Operation:    kwhmodel.OperationCreate,
+				NewObjectRaw: []byte(object),
+			},
+		},
+
+		"An admission review update should be mutated with the operation, old object and dry run.": {
+			body: fmt.Sprintf(`{
+  "kind": "AdmissionReview",
+  "apiVersion": "admission.k8s.io/v1",
+  "request": {
+    "uid": "test",
+    "operation": "UPDATE",
+    "namespace": "test-ns",
+    "dryRun": true,
+    "userInfo": {"username": "alice", "groups": ["team-a"]},
+    "oldObject": %s,
+    "object": %s
+  }
+}`, oldObject, object),
+			expAR: &kwhmodel.AdmissionReview{
+				Name:         "test",
+				Namespace:    "test-ns",
+				Operation:    kwhmodel.OperationUpdate,
+				OldObjectRaw: []byte(oldObject),
+				NewObjectRaw: []byte(object),
+				DryRun:       true,
+				UserInfo:     authenticationv1.UserInfo{Username: "alice", Groups: []string{"team-a"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := &testRecorderMutator{}
+			h, err := debug.New(debug.Config{Mutator: m})
+			require.NoError(err)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/mutate", strings.NewReader(test.body)))
+			require.Equal(http.StatusOK, w.Code)
+
+			require.NotNil(m.ar)
+			assert.Equal(test.expAR.Operation, m.ar.Operation)
+			assert.Equal(test.expAR.Namespace, m.ar.Namespace)
+			assert.Equal(test.expAR.Name, m.ar.Name)
+			assert.Equal(test.expAR.DryRun, m.ar.DryRun)
+			assert.Equal(test.expAR.UserInfo, m.ar.UserInfo)
+			assert.JSONEq(string(test.expAR.NewObjectRaw), string(m.ar.NewObjectRaw))
+			if test.expAR.OldObjectRaw == nil {
+				assert.Empty(m.ar.OldObjectRaw)
+			} else {
+				assert.JSONEq(string(test.expAR.OldObjectRaw), string(m.ar.OldObjectRaw))
+			}
+		})
+	}
+}
+
+// webhookResult is the combined result of the webhooks on an admission review.
+type webhookResult struct {
+	allowed  bool
+	message  string
+	warnings []string
+	patch    []map[string]interface{}
+}
+
+// reviewWebhook sends the admission review to the webhook and returns its response.
+func reviewWebhook(t *testing.T, wh http.Handler, path, ar string) *admissionv1.AdmissionResponse {
+	require := require.New(t)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(ar))
+	wh.ServeHTTP(w, r)
+	require.Equal(http.StatusOK, w.Code)
+
+	review := admissionv1.AdmissionReview{}
+	err := json.Unmarshal(w.Body.Bytes(), &review)
+	require.NoError(err)
+	require.NotNil(review.Response)
+
+	return review.Response
+}
+
+func TestHandlerMatchesWebhooks(t *testing.T) {
+	tests := map[string]struct {
+		namespace      string
+		operation      string
+		oldObject      string
+		object         string
+		kind           string
+		mutatingPath   string
+		validatingPath string
+	}{
+		"A marked object.": {
+			object:       `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "test-ns", "labels": {"app": "test"}}}`,
+			kind:         `{"group": "", "version": "v1", "kind": "ConfigMap"}`,
+			mutatingPath: "/wh/mutating/allmark",
+		},
+
+		"An updated marked object.": {
+			operation:    "UPDATE",
+			oldObject:    `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "test-ns"}}`,
+			object:       `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "test-ns", "labels": {"app": "test"}}}`,
+			kind:         `{"group": "", "version": "v1", "kind": "ConfigMap"}`,
+			mutatingPath: "/wh/mutating/allmark",
+		},
+
+		"An object rejected by the marker.": {
+			object:       `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "test-ns", "labels": {"team": "team-b"}}}`,
+			kind:         `{"group": "", "version": "v1", "kind": "ConfigMap"}`,
+			mutatingPath: "/wh/mutating/allmark",
+		},
+
+		"An exempted object.": {
+			namespace:    "kube-system",
+			object:       `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"name": "test", "namespace": "kube-system"}}`,
+			kind:         `{"group": "", "version": "v1", "kind": "ConfigMap"}`,
+			mutatingPath: "/wh/mutating/allmark",
+		},
+
+		"A mutated service monitor.": {
+			object:         `{"kind": "ServiceMonitor", "apiVersion": "monitoring.coreos.com/v1", "metadata": {"name": "test", "namespace": "test-ns"}, "spec": {"endpoints": [{"interval": "1s"}], "selector": {}}}`,
+			kind:           `{"group": "monitoring.coreos.com", "version": "v1", "kind": "ServiceMonitor"}`,
+			mutatingPath:   "/wh/mutating/safeservicemonitor",
+			validatingPath: "/wh/validating/safeservicemonitor",
+		},
+
+		"A pod monitor denied by the safety rules in validate mode.": {
+			object:         `{"kind": "PodMonitor", "apiVersion": "monitoring.coreos.com/v1", "metadata": {"name": "test", "namespace": "test-ns"}, "spec": {"podMetricsEndpoints": [{"interval": "5s"}], "selector": {}}}`,
+			kind:           `{"group": "monitoring.coreos.com", "version": "v1", "kind": "PodMonitor"}`,
+			mutatingPath:   "/wh/mutating/safepodmonitor",
+			validatingPath: "/wh/validating/safepodmonitor",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			mcfg := newTestMutatorConfig(t)
+			wh, err := webhook.New(webhook.Config{
+				Exempter:                       mcfg.Exempter,
+				Marker:                         mcfg.Marker,
+				IngressRegexHostValidator:      ingress.DummyValidator,
+				IngressSingleHostValidator:     ingress.DummyValidator,
+				IngressHostPolicyValidator:     ingress.DummyValidator,
+				IngressNSHostPolicyValidator:   ingress.DummyValidator,
+				IngressHostUniquenessValidator: ingress.DummyValidator,
+				IngressTLSValidator:            ingress.DummyValidator,
+				IngressBackendValidator:        ingress.DummyValidator,
+				IngressClassValidator:          ingress.DummyValidator,
+				IngressAnnotationValidator:     ingress.DummyValidator,
+				ServiceMonitorSafer:            mcfg.ServiceMonitorSafer,
+				PodMonitorSafer:                mcfg.PodMonitorSafer,
+				ProbeSafer:                     mcfg.ProbeSafer,
+			})
+			require.NoError(err)
+
+			namespace := test.namespace
+			if namespace == "" {
+				namespace = "test-ns"
+			}
+			operation, oldObject := test.operation, test.oldObject
+			if operation == "" {
+				operation = "CREATE"
+			}
+			if oldObject == "" {
+				oldObject = "null"
+			}
+			ar := fmt.Sprintf(`{
+  "kind": "AdmissionReview",
+  "apiVersion": "admission.k8s.io/v1",
+  "request": {
+    "uid": "test",
+    "kind": %[1]s,
+    "requestKind": %[1]s,
+    "operation": %[4]q,
+    "namespace": %[3]q,
+    "userInfo": {"username": "alice"},
+    "oldObject": %[5]s,
+    "object": %[2]s
+  }
+}`, test.kind, test.object, namespace, operation, oldObject)
+
+			// Get the result of the webhooks, like the cluster would have.
+			exp := webhookResult{allowed: true}
+			mutResp := reviewWebhook(t, wh, test.mutatingPath, ar)
+			exp.allowed = mutResp.Allowed
+			exp.warnings = mutResp.Warnings
+			if mutResp.Result != nil {
+				exp.message = mutResp.Result.Message
+			}
+			if len(mutResp.Patch) > 0 {
+				err := json.Unmarshal(mutResp.Patch, &exp.patch)
+				require.NoError(err)
+			}
+			if exp.allowed && test.validatingPath != "" {
+				valResp := reviewWebhook(t, wh, test.validatingPath, ar)
+				if !valResp.Allowed {
+					exp.allowed = false
+					exp.message = valResp.Result.Message
+				}
+			}
+			if !exp.allowed {
+				exp.patch = nil
+			}
+
+			// Get the result of the debug handler.
+			h, err := debug.New(debug.Config{Mutator: newTestMutator(t)})
+			require.NoError(err)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/debug/mutate", strings.NewReader(ar)))
+			require.Equal(http.StatusOK, w.Code)
+
+			resp := struct {
+				Allowed  bool                     `json:"allowed"`
+				Message  string                   `json:"message"`
+				Warnings []string                 `json:"warnings"`
+				Patch    []map[string]interface{} `json:"patch"`
+			}{}
+			err = json.Unmarshal(w.Body.Bytes(), &resp)
+			require.NoError(err)
+			if len(resp.Patch) == 0 {
+				resp.Patch = nil
+			}
+
+			assert.Equal(exp.allowed, resp.Allowed)
+			assert.Equal(exp.message, resp.Message)
+			assert.Equal(exp.warnings, resp.Warnings)
+			// The webhooks patch also has the decoding changes of the received object (e.g:
+			// `creationTimestamp: null`) that the debug handler doesn't return.
+			assert.Subset(exp.patch, resp.Patch)
+		})
+	}
+}
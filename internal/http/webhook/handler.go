@@ -14,6 +14,8 @@ import (
 	kwhmutating "github.com/slok/kubewebhook/v2/pkg/webhook/mutating"
 	kwhvalidating "github.com/slok/kubewebhook/v2/pkg/webhook/validating"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientsetscheme "k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/slok/k8s-webhook-example/internal/log"
 	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
@@ -34,23 +36,32 @@ func (l kubewebhookLogger) SetValuesOnCtx(parent context.Context, values map[str
 	return kwhlog.CtxWithValues(parent, values)
 }
 
+// exempted returns true if the admission review object is exempted from the webhook. If the
+// exemption can't be checked, the object will not be exempted.
+func (h handler) exempted(ctx context.Context, webhookID string, ar *kwhmodel.AdmissionReview, obj metav1.Object) bool {
+	return h.mutator.exempted(ctx, webhookID, ar, obj)
+}
+
+const allMarkWebhookID = "allMark"
+
 // allmark sets up the webhook handler for marking all kubernetes resources using Kubewebhook library.
 func (h handler) allMark() (http.Handler, error) {
 	mt := kwhmutating.MutatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhmutating.MutatorResult, error) {
-		err := h.marker.Mark(ctx, obj)
+		res, err := h.mutator.mark(ctx, allMarkWebhookID, ar, obj)
 		if err != nil {
-			return nil, fmt.Errorf("could not mark the resource: %w", err)
+			return nil, err
 		}
 
-		return &kwhmutating.MutatorResult{
-			MutatedObject: obj,
-			Warnings:      []string{"Resource marked with custom labels"},
-		}, nil
+		if !res.Allowed {
+			return nil, mutationDeniedError{msg: res.Message}
+		}
+
+		return &kwhmutating.MutatorResult{MutatedObject: obj, Warnings: res.Warnings}, nil
 	})
 
-	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": "allMark"})}
+	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": allMarkWebhookID})}
 	wh, err := kwhmutating.NewWebhook(kwhmutating.WebhookConfig{
-		ID:      "allMark",
+		ID:      allMarkWebhookID,
 		Logger:  logger,
 		Mutator: mt,
 	})
@@ -58,7 +69,7 @@ func (h handler) allMark() (http.Handler, error) {
 		return nil, fmt.Errorf("could not create webhook: %w", err)
 	}
 	whHandler, err := kwhhttp.HandlerFor(kwhhttp.HandlerConfig{
-		Webhook: kwhwebhook.NewMeasuredWebhook(h.metrics, wh),
+		Webhook: kwhwebhook.NewMeasuredWebhook(h.metrics, deniableWebhook{Webhook: wh}),
 		Logger:  logger,
 	})
 	if err != nil {
@@ -68,53 +79,142 @@ func (h handler) allMark() (http.Handler, error) {
 	return whHandler, nil
 }
 
-// ingressValidation sets up the webhook handler for validating an ingress using a chain of validations.
-// Thec validation chain will check first if the ingress has a single host, if not it will stop the
-// validation chain, otherwirse it will check the nest ingress Validator that will try matching the host
-// with allowed host.
-func (h handler) ingressValidation() (http.Handler, error) {
-	// Single host validator.
-	vSingle := kwhvalidating.ValidatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
-		err := h.ingSingleHostVal.Validate(ctx, obj)
+const ingressValidationWebhookID = "ingressValidation"
+
+// ingressValidator is an ingress validation of the ingress validation webhook.
+type ingressValidator struct {
+	// code is the stable ID of the validation rule.
+	code      string
+	msg       string
+	validator ingress.Validator
+}
+
+// ingressValidators returns the ingress validations in the order they will be executed.
+func (h handler) ingressValidators() []ingressValidator {
+	return []ingressValidator{
+		{code: "single-host", msg: "ingress is invalid", validator: h.ingSingleHostVal},
+		{code: "host-regex", msg: "ingress host is invalid", validator: h.ingRegexHostVal},
+		{code: "host-policy", msg: "ingress host is invalid", validator: h.ingHostPolicyVal},
+		{code: "namespace-host-policy", msg: "ingress host is invalid for the namespace", validator: h.ingNSHostPolVal},
+		{code: "host-uniqueness", msg: "ingress host is invalid", validator: h.ingHostUniqVal},
+		{code: "tls", msg: "ingress TLS is invalid", validator: h.ingTLSVal},
+		{code: "backend", msg: "ingress backends are invalid", validator: h.ingBackendVal},
+		{code: "class", msg: "ingress class is invalid", validator: h.ingClassVal},
+		{code: "annotation", msg: "ingress annotations are invalid", validator: h.ingAnnotVal},
+	}
+}
+
+// kubewebhookValidator returns a Kubewebhook validator for an ingress validator, the invalid
+// results will be enforced, warned or audited depending on the validator mode.
+func (h handler) kubewebhookValidator(iv ingressValidator) kwhvalidating.Validator {
+	mode, ok := h.ingValModes[iv.code]
+	if !ok {
+		mode = ValidationModeEnforce
+	}
+
+	return kwhvalidating.ValidatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
+		// The object could not have the namespace set (e.g: created without namespace in the manifest),
+		// the admission review namespace is the one that will be used.
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(ar.Namespace)
+		}
+
+		err := iv.validator.Validate(ctx, obj)
 		if err != nil {
 			if errors.Is(err, ingress.ErrNotIngress) {
 				h.logger.Warningf("received object is not an ingress")
 				return &kwhvalidating.ValidatorResult{Valid: true}, nil
 			}
 
+			msg := fmt.Sprintf("%s: %s", iv.msg, err)
+			switch mode {
+			case ValidationModeWarn:
+				h.metrics.IncValidationNotEnforcedDenial(ctx, ingressValidationWebhookID, iv.code, ar.Namespace, mode)
+				return &kwhvalidating.ValidatorResult{
+					Valid:    true,
+					Warnings: []string{fmt.Sprintf("[%s] %s", iv.code, msg)},
+				}, nil
+			case ValidationModeAudit:
+				h.metrics.IncValidationNotEnforcedDenial(ctx, ingressValidationWebhookID, iv.code, ar.Namespace, mode)
+				h.logger.WithKV(log.KV{"validator": iv.code, "ns": ar.Namespace, "name": ar.Name}).Warningf("audit mode validation denial: %s", msg)
+				return &kwhvalidating.ValidatorResult{Valid: true}, nil
+			}
+
 			return &kwhvalidating.ValidatorResult{
-				Message: fmt.Sprintf("ingress is invalid: %s", err),
+				Message: msg,
 				Valid:   false,
 			}, nil
 		}
 
 		return &kwhvalidating.ValidatorResult{Valid: true}, nil
 	})
+}
 
-	// Host based on regex validator.
-	vRegex := kwhvalidating.ValidatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
-		err := h.ingRegexHostVal.Validate(ctx, obj)
-		if err != nil {
-			if errors.Is(err, ingress.ErrNotIngress) {
-				h.logger.Warningf("received object is not an ingress")
-				return &kwhvalidating.ValidatorResult{Valid: true}, nil
-			}
+// ingressValidation sets up the webhook handler for validating an ingress using a chain of validations.
+// The validation chain will check first if the ingress has a single host, if not it will stop the
+// validation chain, otherwise it will check the next ingress validators that will try matching the host
+// with allowed host regexes, host policy patterns and the host policy patterns of the ingress namespace,
+// that the hosts and paths are not already claimed by other ingresses of the cluster, and finally
+// that the ingress TLS section, paths, backends, class and annotations are correct.
+// If all errors mode is enabled, instead of a chain that stops on the first failure, all the validations
+// will be executed and the invalid results will be aggregated in a single one.
+func (h handler) ingressValidation() (http.Handler, error) {
+	// Check the validator modes are for known validators.
+	codes := map[string]bool{}
+	for _, iv := range h.ingressValidators() {
+		codes[iv.code] = true
+	}
+	for code := range h.ingValModes {
+		if !codes[code] {
+			return nil, fmt.Errorf("unknown ingress validator %q", code)
+		}
+	}
 
-			return &kwhvalidating.ValidatorResult{
-				Message: fmt.Sprintf("ingress host is invalid: %s", err),
-				Valid:   false,
-			}, nil
+	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": ingressValidationWebhookID})}
+
+	var v kwhvalidating.Validator
+	if h.ingAllErrors {
+		validators := aggregatedValidator{}
+		for _, iv := range h.ingressValidators() {
+			validators = append(validators, codedValidator{code: iv.code, validator: h.kubewebhookValidator(iv)})
+		}
+		v = validators
+	} else {
+		validators := []kwhvalidating.Validator{}
+		for _, iv := range h.ingressValidators() {
+			validators = append(validators, h.kubewebhookValidator(iv))
 		}
 
-		return &kwhvalidating.ValidatorResult{Valid: true}, nil
-	})
+		// Create a chain with all ingress validations and use these to create the kwhwebhook.
+		v = kwhvalidating.NewChain(logger, validators...)
+	}
 
-	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": "ingressValidation"})}
+	// Skip all the validations on exempted objects.
+	validator := v
+	v = kwhvalidating.ValidatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
+		if h.exempted(ctx, ingressValidationWebhookID, ar, obj) {
+			return &kwhvalidating.ValidatorResult{Valid: true}, nil
+		}
+
+		// On updates, the validators can use the old ingress to only validate the changes.
+		if ar.Operation == kwhmodel.OperationUpdate && len(ar.OldObjectRaw) > 0 {
+			old, _, err := clientsetscheme.Codecs.UniversalDeserializer().Decode(ar.OldObjectRaw, nil, nil)
+			if err != nil {
+				return nil, fmt.Errorf("could not decode old ingress: %w", err)
+			}
+			if oldObj, ok := old.(metav1.Object); ok {
+				if oldObj.GetNamespace() == "" {
+					oldObj.SetNamespace(ar.Namespace)
+				}
+				ctx = ingress.ContextWithOldIngress(ctx, oldObj)
+			}
+		}
+
+		return validator.Validate(ctx, ar, obj)
+	})
 
-	// Create a chain with both ingress validations and use these to create the kwhwebhook.
-	v := kwhvalidating.NewChain(logger, vSingle, vRegex)
 	wh, err := kwhvalidating.NewWebhook(kwhvalidating.WebhookConfig{
-		ID:        "ingressValidation",
+		ID:        ingressValidationWebhookID,
 		Validator: v,
 		Logger:    logger,
 	})
@@ -135,31 +235,207 @@ func (h handler) ingressValidation() (http.Handler, error) {
 
 // safeServiceMonitor sets up the webhook handler to set safety Prometheus service monitor CR settings.
 func (h handler) safeServiceMonitor() (http.Handler, error) {
+	return h.safeMonitoring(h.mutator.servMonMonitoringSafer())
+}
+
+// safeServiceMonitorValidation sets up the webhook handler to deny unsafe Prometheus service monitor CRs.
+func (h handler) safeServiceMonitorValidation() (http.Handler, error) {
+	return h.safeMonitoringValidation(h.mutator.servMonMonitoringSafer())
+}
+
+// safePodMonitor sets up the webhook handler to set safety Prometheus pod monitor CR settings.
+func (h handler) safePodMonitor() (http.Handler, error) {
+	return h.safeMonitoring(h.mutator.podMonMonitoringSafer())
+}
+
+// safePodMonitorValidation sets up the webhook handler to deny unsafe Prometheus pod monitor CRs.
+func (h handler) safePodMonitorValidation() (http.Handler, error) {
+	return h.safeMonitoringValidation(h.mutator.podMonMonitoringSafer())
+}
+
+// safeProbe sets up the webhook handler to set safety Prometheus probe CR settings.
+func (h handler) safeProbe() (http.Handler, error) {
+	return h.safeMonitoring(h.mutator.probeMonitoringSafer())
+}
+
+// safeProbeValidation sets up the webhook handler to deny unsafe Prometheus probe CRs.
+func (h handler) safeProbeValidation() (http.Handler, error) {
+	return h.safeMonitoringValidation(h.mutator.probeMonitoringSafer())
+}
+
+// safeMonitoring sets up the webhook handler to set safety settings on a Prometheus operator
+// monitoring CR (e.g: service monitors).
+func (h handler) safeMonitoring(ms monitoringSafer) (http.Handler, error) {
 	mt := kwhmutating.MutatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhmutating.MutatorResult, error) {
+		// The unsafe settings of the rules in validate mode are denied by the validating webhook.
+		res, err := h.mutator.safeMonitoring(ctx, ms.webhookID, ms, ar, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		return &kwhmutating.MutatorResult{MutatedObject: obj, Warnings: res.Warnings}, nil
+	})
+
+	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": ms.webhookID})}
+
+	// Create a static webhook, placing the specific object we are going to redeive, this is important
+	// so we receive a CR instead of `runtume.Unstructured` on the mutator.
+	wh, err := kwhmutating.NewWebhook(kwhmutating.WebhookConfig{
+		ID:      ms.webhookID,
+		Obj:     ms.obj,
+		Mutator: mt,
+		Logger:  logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create webhook: %w", err)
+	}
+
+	whHandler, err := kwhhttp.HandlerFor(kwhhttp.HandlerConfig{
+		Webhook: kwhwebhook.NewMeasuredWebhook(h.metrics, wh),
+		Logger:  logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create handler from webhook: %w", err)
+	}
+
+	return whHandler, nil
+}
+
+// safeMonitoringValidation sets up the webhook handler to deny the Prometheus operator monitoring
+// CRs that don't have safe settings. It uses the same safety rules as the mutating webhook, but only
+// the rules in validate mode will deny the CRs.
+func (h handler) safeMonitoringValidation(ms monitoringSafer) (http.Handler, error) {
+	webhookID := ms.webhookID + "Validation"
+
+	v := kwhvalidating.ValidatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
+		// Don't mutate the received object, we only want the violations.
+		robj, ok := obj.(runtime.Object)
+		if !ok {
+			return nil, fmt.Errorf("received object is not a runtime object")
+		}
+
+		res, err := h.mutator.safeMonitoring(ctx, webhookID, ms, ar, robj.DeepCopyObject().(metav1.Object))
+		if err != nil {
+			return nil, err
+		}
+
+		return &kwhvalidating.ValidatorResult{Valid: res.Allowed, Message: res.Message}, nil
+	})
+
+	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": webhookID})}
+
+	wh, err := kwhvalidating.NewWebhook(kwhvalidating.WebhookConfig{
+		ID:        webhookID,
+		Obj:       ms.obj,
+		Validator: v,
+		Logger:    logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create webhook: %w", err)
+	}
+
+	whHandler, err := kwhhttp.HandlerFor(kwhhttp.HandlerConfig{
+		Webhook: kwhwebhook.NewMeasuredWebhook(h.metrics, wh),
+		Logger:  logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create handler from webhook: %w", err)
+	}
+
+	return whHandler, nil
+}
+
+const serviceMonitorValidationWebhookID = "serviceMonitorValidation"
+
+// serviceMonitorValidation sets up the webhook handler for validating the Prometheus service monitor CRs
+// (e.g: the relabelings).
+func (h handler) serviceMonitorValidation() (http.Handler, error) {
+	v := kwhvalidating.ValidatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
 		sm, ok := obj.(*monitoringv1.ServiceMonitor)
 		if !ok {
 			h.logger.Warningf("received object is not an monitoringv1.ServiceMonitor")
-			return &kwhmutating.MutatorResult{}, nil
+			return &kwhvalidating.ValidatorResult{Valid: true}, nil
+		}
+
+		if h.exempted(ctx, serviceMonitorValidationWebhookID, ar, sm) {
+			return &kwhvalidating.ValidatorResult{Valid: true}, nil
+		}
+
+		// The object could not have the namespace set (e.g: created without namespace in the manifest),
+		// the admission review namespace is the one that will be used.
+		if sm.Namespace == "" {
+			sm.Namespace = ar.Namespace
 		}
 
-		err := h.servMonSafer.EnsureSafety(ctx, sm)
+		err := h.servMonVal.Validate(ctx, sm)
 		if err != nil {
-			return nil, fmt.Errorf("could not set safety settings on service monitor: %w", err)
+			return &kwhvalidating.ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("service monitor is invalid: %s", err),
+			}, nil
 		}
 
-		return &kwhmutating.MutatorResult{MutatedObject: sm}, nil
+		return &kwhvalidating.ValidatorResult{Valid: true}, nil
 	})
 
-	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": "safeServiceMonitor"})}
+	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": serviceMonitorValidationWebhookID})}
 
-	// Create a static webhook, placing the specific object we are going to redeive, this is important
-	// so we receive a CR instead of `runtume.Unstructured` on the mutator.
-	wh, err := kwhmutating.NewWebhook(kwhmutating.WebhookConfig{
-		ID:      "safeServiceMonitor",
-		Obj:     &monitoringv1.ServiceMonitor{},
-		Mutator: mt,
+	wh, err := kwhvalidating.NewWebhook(kwhvalidating.WebhookConfig{
+		ID:        serviceMonitorValidationWebhookID,
+		Obj:       &monitoringv1.ServiceMonitor{},
+		Validator: v,
+		Logger:    logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create webhook: %w", err)
+	}
+
+	whHandler, err := kwhhttp.HandlerFor(kwhhttp.HandlerConfig{
+		Webhook: kwhwebhook.NewMeasuredWebhook(h.metrics, wh),
 		Logger:  logger,
 	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create handler from webhook: %w", err)
+	}
+
+	return whHandler, nil
+}
+
+const prometheusRuleValidationWebhookID = "prometheusRuleValidation"
+
+// prometheusRuleValidation sets up the webhook handler for validating the Prometheus rule CRs
+// (e.g: the PromQL expressions).
+func (h handler) prometheusRuleValidation() (http.Handler, error) {
+	v := kwhvalidating.ValidatorFunc(func(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
+		pr, ok := obj.(*monitoringv1.PrometheusRule)
+		if !ok {
+			h.logger.Warningf("received object is not an monitoringv1.PrometheusRule")
+			return &kwhvalidating.ValidatorResult{Valid: true}, nil
+		}
+
+		if h.exempted(ctx, prometheusRuleValidationWebhookID, ar, pr) {
+			return &kwhvalidating.ValidatorResult{Valid: true}, nil
+		}
+
+		err := h.promRuleVal.Validate(ctx, pr)
+		if err != nil {
+			return &kwhvalidating.ValidatorResult{
+				Valid:   false,
+				Message: fmt.Sprintf("prometheus rule is invalid: %s", err),
+			}, nil
+		}
+
+		return &kwhvalidating.ValidatorResult{Valid: true}, nil
+	})
+
+	logger := kubewebhookLogger{Logger: h.logger.WithKV(log.KV{"lib": "kubewebhook", "webhook": prometheusRuleValidationWebhookID})}
+
+	wh, err := kwhvalidating.NewWebhook(kwhvalidating.WebhookConfig{
+		ID:        prometheusRuleValidationWebhookID,
+		Obj:       &monitoringv1.PrometheusRule{},
+		Validator: v,
+		Logger:    logger,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not create webhook: %w", err)
 	}
@@ -1,24 +1,55 @@
 package webhook
 
 import (
+	"context"
+
 	gohttpmetrics "github.com/slok/go-http-metrics/metrics"
 	"github.com/slok/kubewebhook/v2/pkg/webhook"
+
+	"github.com/slok/k8s-webhook-example/internal/exemption"
 )
 
 // MetricsRecorder is the service used to record metrics in the internal HTTP webhook.
 type MetricsRecorder interface {
 	gohttpmetrics.Recorder
 	webhook.MetricsRecorder
+	validationRecorder
+	exemptionRecorder
+}
+
+type validationRecorder interface {
+	// IncValidationNotEnforcedDenial records a denial of a validation that has not been
+	// enforced because the validation is in a non enforcing mode (e.g: warn, audit).
+	IncValidationNotEnforcedDenial(ctx context.Context, webhookID, validator, namespace string, mode ValidationMode)
+}
+
+type exemptionRecorder interface {
+	// IncExemptedRequest records a request that has been skipped by a webhook because the
+	// object has been exempted.
+	IncExemptedRequest(ctx context.Context, webhookID string, reason exemption.Reason)
 }
 
 // Types used to avoid collisions with the same interface naming.
 type httpRecorder gohttpmetrics.Recorder
 type webhookRecorder webhook.MetricsRecorder
 
+type dummyValidationRecorder int
+
+func (dummyValidationRecorder) IncValidationNotEnforcedDenial(_ context.Context, _, _, _ string, _ ValidationMode) {
+}
+
+type dummyExemptionRecorder int
+
+func (dummyExemptionRecorder) IncExemptedRequest(_ context.Context, _ string, _ exemption.Reason) {}
+
 var dummyMetricsRecorder = struct {
 	httpRecorder
 	webhookRecorder
+	validationRecorder
+	exemptionRecorder
 }{
-	httpRecorder:    gohttpmetrics.Dummy,
-	webhookRecorder: webhook.NoopMetricsRecorder,
+	httpRecorder:       gohttpmetrics.Dummy,
+	webhookRecorder:    webhook.NoopMetricsRecorder,
+	validationRecorder: dummyValidationRecorder(0),
+	exemptionRecorder:  dummyExemptionRecorder(0),
 }
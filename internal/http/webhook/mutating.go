@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+
+	kwhmodel "github.com/slok/kubewebhook/v2/pkg/model"
+	kwhwebhook "github.com/slok/kubewebhook/v2/pkg/webhook"
+)
+
+// mutationDeniedError is the error that mutators can return to deny the object instead of
+// failing the webhook.
+type mutationDeniedError struct {
+	msg string
+}
+
+func (e mutationDeniedError) Error() string { return e.msg }
+
+// deniableWebhook wraps a mutating webhook so the mutators can deny objects, Kubewebhook mutating
+// webhooks can only mutate or fail, so the `mutationDeniedError` errors will be converted into
+// admission denials, instead of webhook errors.
+type deniableWebhook struct {
+	kwhwebhook.Webhook
+}
+
+func (d deniableWebhook) Review(ctx context.Context, ar kwhmodel.AdmissionReview) (kwhmodel.AdmissionResponse, error) {
+	resp, err := d.Webhook.Review(ctx, ar)
+	if err != nil {
+		var denied mutationDeniedError
+		if errors.As(err, &denied) {
+			return &kwhmodel.ValidatingAdmissionResponse{
+				ID:      ar.ID,
+				Allowed: false,
+				Message: denied.msg,
+			}, nil
+		}
+
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	kwhmodel "github.com/slok/kubewebhook/v2/pkg/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/exemption"
+	"github.com/slok/k8s-webhook-example/internal/log"
+	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
+	"github.com/slok/k8s-webhook-example/internal/mutation/prometheus"
+)
+
+// MutatorConfig is the mutator configuration.
+type MutatorConfig struct {
+	MetricsRecorder MetricsRecorder
+	// Exempter is used to skip the exempted objects, by default no object is exempted.
+	Exempter            exemption.Exempter
+	Marker              mark.Marker
+	ServiceMonitorSafer prometheus.ServiceMonitorSafer
+	PodMonitorSafer     prometheus.PodMonitorSafer
+	ProbeSafer          prometheus.ProbeSafer
+	Logger              log.Logger
+}
+
+func (c *MutatorConfig) defaults() error {
+	if c.Marker == nil {
+		return fmt.Errorf("marker is required")
+	}
+
+	if c.ServiceMonitorSafer == nil {
+		return fmt.Errorf("service monitor safer is required")
+	}
+
+	if c.PodMonitorSafer == nil {
+		return fmt.Errorf("pod monitor safer is required")
+	}
+
+	if c.ProbeSafer == nil {
+		return fmt.Errorf("probe safer is required")
+	}
+
+	if c.Exempter == nil {
+		c.Exempter = exemption.DummyExempter
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = dummyMetricsRecorder
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Dummy
+	}
+
+	return nil
+}
+
+// MutationResult is the result of a mutation.
+type MutationResult struct {
+	// Allowed is false when the object is denied.
+	Allowed bool
+	// Message is the denial message.
+	Message  string
+	Warnings []string
+}
+
+// Mutator knows how to mutate objects like the mutating webhooks do, the received object
+// will be mutated in place.
+type Mutator interface {
+	Mutate(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*MutationResult, error)
+}
+
+// NewMutator returns the mutator used by the webhooks. The service monitors, pod monitors and
+// probes will be mutated with their safers and the rest of the objects with the marker, the
+// exempted objects will not be mutated.
+// The safety rules in validate mode will deny the objects, like the safer validating webhooks do,
+// so the result is the same the cluster would have with all the webhooks.
+// This is useful to check the mutations outside the webhooks (e.g: debug endpoint).
+func NewMutator(config MutatorConfig) (Mutator, error) {
+	m, err := newMutator(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func newMutator(config MutatorConfig) (mutator, error) {
+	err := config.defaults()
+	if err != nil {
+		return mutator{}, fmt.Errorf("mutator configuration is not valid: %w", err)
+	}
+
+	return mutator{
+		exempter:     config.Exempter,
+		marker:       config.Marker,
+		servMonSafer: config.ServiceMonitorSafer,
+		podMonSafer:  config.PodMonitorSafer,
+		probeSafer:   config.ProbeSafer,
+		metrics:      config.MetricsRecorder,
+		logger:       config.Logger,
+	}, nil
+}
+
+type mutator struct {
+	exempter     exemption.Exempter
+	marker       mark.Marker
+	servMonSafer prometheus.ServiceMonitorSafer
+	podMonSafer  prometheus.PodMonitorSafer
+	probeSafer   prometheus.ProbeSafer
+	metrics      MetricsRecorder
+	logger       log.Logger
+}
+
+func (m mutator) Mutate(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*MutationResult, error) {
+	var ms monitoringSafer
+	switch obj.(type) {
+	case *monitoringv1.ServiceMonitor:
+		ms = m.servMonMonitoringSafer()
+	case *monitoringv1.PodMonitor:
+		ms = m.podMonMonitoringSafer()
+	case *monitoringv1.Probe:
+		ms = m.probeMonitoringSafer()
+	default:
+		return m.mark(ctx, allMarkWebhookID, ar, obj)
+	}
+
+	return m.safeMonitoring(ctx, ms.webhookID, ms, ar, obj)
+}
+
+// exempted returns true if the admission review object is exempted from the webhook. If the
+// exemption can't be checked, the object will not be exempted.
+func (m mutator) exempted(ctx context.Context, webhookID string, ar *kwhmodel.AdmissionReview, obj metav1.Object) bool {
+	logger := m.logger.WithKV(log.KV{"webhook": webhookID, "ns": ar.Namespace, "name": ar.Name})
+
+	res, err := m.exempter.Exempt(ctx, exemption.Request{
+		Namespace: ar.Namespace,
+		Object:    obj,
+		Username:  ar.UserInfo.Username,
+		Groups:    ar.UserInfo.Groups,
+	})
+	if err != nil {
+		logger.Errorf("could not check object exemption: %s", err)
+		return false
+	}
+
+	if !res.Exempted {
+		return false
+	}
+
+	m.metrics.IncExemptedRequest(ctx, webhookID, res.Reason)
+	logger.Debugf("object exempted by %s", res.Reason)
+
+	return true
+}
+
+// mark marks the object with the marker.
+func (m mutator) mark(ctx context.Context, webhookID string, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*MutationResult, error) {
+	if m.exempted(ctx, webhookID, ar, obj) {
+		return &MutationResult{Allowed: true}, nil
+	}
+
+	// The object could not have the namespace set (e.g: created without namespace in the manifest),
+	// the admission review namespace is the one that will be used.
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(ar.Namespace)
+	}
+
+	ctx = mark.ContextWithUsername(ctx, ar.UserInfo.Username)
+	res, err := m.marker.Mark(ctx, obj)
+	if err != nil {
+		if errors.Is(err, mark.ErrMarkRejected) {
+			return &MutationResult{
+				Allowed: false,
+				Message: fmt.Sprintf("resource marks are invalid: %s", err),
+			}, nil
+		}
+		return nil, fmt.Errorf("could not mark the resource: %w", err)
+	}
+
+	return &MutationResult{
+		Allowed:  true,
+		Warnings: append([]string{"Resource marked with custom labels"}, res.Warnings...),
+	}, nil
+}
+
+// monitoringSafer is a Prometheus operator monitoring resource safer used by the safe monitoring webhooks.
+type monitoringSafer struct {
+	// webhookID is the ID of the mutating webhook, the validating webhook will use the `Validation` suffix.
+	webhookID string
+	// name is the human readable name of the resource.
+	name string
+	// obj is the specific type that the static webhooks will receive.
+	obj metav1.Object
+	// ensureSafety ensures the safety of the resource, it will return false if the object is not
+	// of the expected type.
+	ensureSafety func(ctx context.Context, obj metav1.Object) (*prometheus.Result, bool, error)
+}
+
+const safeServiceMonitorWebhookID = "safeServiceMonitor"
+
+func (m mutator) servMonMonitoringSafer() monitoringSafer {
+	return monitoringSafer{
+		webhookID: safeServiceMonitorWebhookID,
+		name:      "service monitor",
+		obj:       &monitoringv1.ServiceMonitor{},
+		ensureSafety: func(ctx context.Context, obj metav1.Object) (*prometheus.Result, bool, error) {
+			sm, ok := obj.(*monitoringv1.ServiceMonitor)
+			if !ok {
+				return nil, false, nil
+			}
+			res, err := m.servMonSafer.EnsureSafety(ctx, sm)
+			return res, true, err
+		},
+	}
+}
+
+const safePodMonitorWebhookID = "safePodMonitor"
+
+func (m mutator) podMonMonitoringSafer() monitoringSafer {
+	return monitoringSafer{
+		webhookID: safePodMonitorWebhookID,
+		name:      "pod monitor",
+		obj:       &monitoringv1.PodMonitor{},
+		ensureSafety: func(ctx context.Context, obj metav1.Object) (*prometheus.Result, bool, error) {
+			pm, ok := obj.(*monitoringv1.PodMonitor)
+			if !ok {
+				return nil, false, nil
+			}
+			res, err := m.podMonSafer.EnsureSafety(ctx, pm)
+			return res, true, err
+		},
+	}
+}
+
+const safeProbeWebhookID = "safeProbe"
+
+func (m mutator) probeMonitoringSafer() monitoringSafer {
+	return monitoringSafer{
+		webhookID: safeProbeWebhookID,
+		name:      "probe",
+		obj:       &monitoringv1.Probe{},
+		ensureSafety: func(ctx context.Context, obj metav1.Object) (*prometheus.Result, bool, error) {
+			p, ok := obj.(*monitoringv1.Probe)
+			if !ok {
+				return nil, false, nil
+			}
+			res, err := m.probeSafer.EnsureSafety(ctx, p)
+			return res, true, err
+		},
+	}
+}
+
+// safeMonitoring sets the safety settings on a Prometheus operator monitoring CR (e.g: service
+// monitors), the unsafe settings of the safety rules in validate mode will deny the CR.
+func (m mutator) safeMonitoring(ctx context.Context, webhookID string, ms monitoringSafer, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*MutationResult, error) {
+	if m.exempted(ctx, webhookID, ar, obj) {
+		return &MutationResult{Allowed: true}, nil
+	}
+
+	res, ok, err := ms.ensureSafety(ctx, obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not set safety settings on %s: %w", ms.name, err)
+	}
+
+	if !ok {
+		m.logger.Warningf("received object is not a %s", ms.name)
+		return &MutationResult{Allowed: true}, nil
+	}
+
+	if len(res.Violations) > 0 {
+		return &MutationResult{
+			Allowed:  false,
+			Message:  fmt.Sprintf("%s is not safe: %s", ms.name, strings.Join(res.Violations, ", ")),
+			Warnings: res.Warnings,
+		}, nil
+	}
+
+	return &MutationResult{Allowed: true, Warnings: res.Warnings}, nil
+}
@@ -24,5 +24,47 @@ func (h handler) routes(router *http.ServeMux) error {
 	}
 	router.Handle("/wh/mutating/safeservicemonitor", safeServiceMonitor)
 
+	safeServiceMonitorVal, err := h.safeServiceMonitorValidation()
+	if err != nil {
+		return err
+	}
+	router.Handle("/wh/validating/safeservicemonitor", safeServiceMonitorVal)
+
+	safePodMonitor, err := h.safePodMonitor()
+	if err != nil {
+		return err
+	}
+	router.Handle("/wh/mutating/safepodmonitor", safePodMonitor)
+
+	safePodMonitorVal, err := h.safePodMonitorValidation()
+	if err != nil {
+		return err
+	}
+	router.Handle("/wh/validating/safepodmonitor", safePodMonitorVal)
+
+	safeProbe, err := h.safeProbe()
+	if err != nil {
+		return err
+	}
+	router.Handle("/wh/mutating/safeprobe", safeProbe)
+
+	safeProbeVal, err := h.safeProbeValidation()
+	if err != nil {
+		return err
+	}
+	router.Handle("/wh/validating/safeprobe", safeProbeVal)
+
+	serviceMonitorVal, err := h.serviceMonitorValidation()
+	if err != nil {
+		return err
+	}
+	router.Handle("/wh/validating/servicemonitor", serviceMonitorVal)
+
+	prometheusRuleVal, err := h.prometheusRuleValidation()
+	if err != nil {
+		return err
+	}
+	router.Handle("/wh/validating/prometheusrule", prometheusRuleVal)
+
 	return nil
 }
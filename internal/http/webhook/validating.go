@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kwhmodel "github.com/slok/kubewebhook/v2/pkg/model"
+	kwhvalidating "github.com/slok/kubewebhook/v2/pkg/webhook/validating"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ValidationMode is the mode a validation will be applied.
+type ValidationMode string
+
+const (
+	// ValidationModeEnforce will deny the invalid objects.
+	ValidationModeEnforce ValidationMode = "enforce"
+	// ValidationModeWarn will allow the invalid objects returning admission warnings.
+	ValidationModeWarn ValidationMode = "warn"
+	// ValidationModeAudit will allow the invalid objects, logging and measuring them.
+	ValidationModeAudit ValidationMode = "audit"
+)
+
+func (v ValidationMode) valid() bool {
+	switch v {
+	case ValidationModeEnforce, ValidationModeWarn, ValidationModeAudit:
+		return true
+	}
+	return false
+}
+
+// codedValidator is a validator identified by a stable code.
+type codedValidator struct {
+	code      string
+	validator kwhvalidating.Validator
+}
+
+// aggregatedValidator is a validator that instead of stopping on the first invalid result like
+// a validation chain, executes all the validators and aggregates all the invalid results in a
+// single one, identifying each failure with its validator code (e.g: `[tls] ingress TLS is invalid: ...`).
+type aggregatedValidator []codedValidator
+
+func (a aggregatedValidator) Validate(ctx context.Context, ar *kwhmodel.AdmissionReview, obj metav1.Object) (*kwhvalidating.ValidatorResult, error) {
+	var warnings []string
+	failures := []string{}
+	for _, cv := range a {
+		res, err := cv.validator.Validate(ctx, ar, obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s validator failed: %w", cv.code, err)
+		}
+
+		if res == nil {
+			return nil, fmt.Errorf("%s validator result can't be `nil`", cv.code)
+		}
+
+		warnings = append(warnings, res.Warnings...)
+		if !res.Valid {
+			failures = append(failures, fmt.Sprintf("[%s] %s", cv.code, res.Message))
+		}
+	}
+
+	if len(failures) > 0 {
+		return &kwhvalidating.ValidatorResult{
+			Valid:    false,
+			Message:  fmt.Sprintf("%d validations failed: %s", len(failures), strings.Join(failures, "; ")),
+			Warnings: warnings,
+		}, nil
+	}
+
+	return &kwhvalidating.ValidatorResult{
+		Valid:    true,
+		Warnings: warnings,
+	}, nil
+}
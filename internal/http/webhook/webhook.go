@@ -4,20 +4,43 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/slok/k8s-webhook-example/internal/exemption"
 	"github.com/slok/k8s-webhook-example/internal/log"
 	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
 	"github.com/slok/k8s-webhook-example/internal/mutation/prometheus"
 	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+	validationprometheus "github.com/slok/k8s-webhook-example/internal/validation/prometheus"
 )
 
 // Config is the handler configuration.
 type Config struct {
-	MetricsRecorder            MetricsRecorder
-	Marker                     mark.Marker
-	IngressRegexHostValidator  ingress.Validator
-	IngressSingleHostValidator ingress.Validator
-	ServiceMonitorSafer        prometheus.ServiceMonitorSafer
-	Logger                     log.Logger
+	MetricsRecorder MetricsRecorder
+	// Exempter is used to skip the exempted objects on all the webhooks, by default no object is exempted.
+	Exempter                       exemption.Exempter
+	Marker                         mark.Marker
+	IngressRegexHostValidator      ingress.Validator
+	IngressSingleHostValidator     ingress.Validator
+	IngressHostPolicyValidator     ingress.Validator
+	IngressNSHostPolicyValidator   ingress.Validator
+	IngressHostUniquenessValidator ingress.Validator
+	IngressTLSValidator            ingress.Validator
+	IngressBackendValidator        ingress.Validator
+	IngressClassValidator          ingress.Validator
+	IngressAnnotationValidator     ingress.Validator
+	// IngressValidationAllErrors will execute all the ingress validations and return all the
+	// failures at once, instead of stopping on the first one.
+	IngressValidationAllErrors bool
+	// IngressValidatorModes are the modes of the ingress validators by validator code, by default
+	// validators are in enforce mode.
+	IngressValidatorModes map[string]ValidationMode
+	ServiceMonitorSafer   prometheus.ServiceMonitorSafer
+	PodMonitorSafer       prometheus.PodMonitorSafer
+	ProbeSafer            prometheus.ProbeSafer
+	// ServiceMonitorValidator validates the service monitors, by default service monitors are not validated.
+	ServiceMonitorValidator validationprometheus.ServiceMonitorValidator
+	// PrometheusRuleValidator validates the Prometheus rules, by default Prometheus rules are not validated.
+	PrometheusRuleValidator validationprometheus.PrometheusRuleValidator
+	Logger                  log.Logger
 }
 
 func (c *Config) defaults() error {
@@ -33,10 +56,64 @@ func (c *Config) defaults() error {
 		return fmt.Errorf("ingress single host validator is required")
 	}
 
+	if c.IngressHostPolicyValidator == nil {
+		return fmt.Errorf("ingress host policy validator is required")
+	}
+
+	if c.IngressNSHostPolicyValidator == nil {
+		return fmt.Errorf("ingress namespace host policy validator is required")
+	}
+
+	if c.IngressHostUniquenessValidator == nil {
+		return fmt.Errorf("ingress host uniqueness validator is required")
+	}
+
+	if c.IngressTLSValidator == nil {
+		return fmt.Errorf("ingress TLS validator is required")
+	}
+
+	if c.IngressBackendValidator == nil {
+		return fmt.Errorf("ingress backend validator is required")
+	}
+
+	if c.IngressClassValidator == nil {
+		return fmt.Errorf("ingress class validator is required")
+	}
+
+	if c.IngressAnnotationValidator == nil {
+		return fmt.Errorf("ingress annotation validator is required")
+	}
+
 	if c.ServiceMonitorSafer == nil {
 		return fmt.Errorf("service monitor safer is required")
 	}
 
+	if c.PodMonitorSafer == nil {
+		return fmt.Errorf("pod monitor safer is required")
+	}
+
+	if c.ProbeSafer == nil {
+		return fmt.Errorf("probe safer is required")
+	}
+
+	for code, mode := range c.IngressValidatorModes {
+		if !mode.valid() {
+			return fmt.Errorf("ingress validator %q mode %q is not valid", code, mode)
+		}
+	}
+
+	if c.Exempter == nil {
+		c.Exempter = exemption.DummyExempter
+	}
+
+	if c.ServiceMonitorValidator == nil {
+		c.ServiceMonitorValidator = validationprometheus.DummyServiceMonitorValidator
+	}
+
+	if c.PrometheusRuleValidator == nil {
+		c.PrometheusRuleValidator = validationprometheus.DummyPrometheusRuleValidator
+	}
+
 	if c.MetricsRecorder == nil {
 		c.MetricsRecorder = dummyMetricsRecorder
 	}
@@ -49,10 +126,20 @@ func (c *Config) defaults() error {
 }
 
 type handler struct {
-	marker           mark.Marker
+	mutator          mutator
 	ingRegexHostVal  ingress.Validator
 	ingSingleHostVal ingress.Validator
-	servMonSafer     prometheus.ServiceMonitorSafer
+	ingHostPolicyVal ingress.Validator
+	ingNSHostPolVal  ingress.Validator
+	ingHostUniqVal   ingress.Validator
+	ingTLSVal        ingress.Validator
+	ingBackendVal    ingress.Validator
+	ingClassVal      ingress.Validator
+	ingAnnotVal      ingress.Validator
+	ingAllErrors     bool
+	ingValModes      map[string]ValidationMode
+	servMonVal       validationprometheus.ServiceMonitorValidator
+	promRuleVal      validationprometheus.PrometheusRuleValidator
 	handler          http.Handler
 	metrics          MetricsRecorder
 	logger           log.Logger
@@ -65,16 +152,40 @@ func New(config Config) (http.Handler, error) {
 		return nil, fmt.Errorf("handler configuration is not valid: %w", err)
 	}
 
+	logger := config.Logger.WithKV(log.KV{"service": "webhook-handler"})
+	mt, err := newMutator(MutatorConfig{
+		MetricsRecorder:     config.MetricsRecorder,
+		Exempter:            config.Exempter,
+		Marker:              config.Marker,
+		ServiceMonitorSafer: config.ServiceMonitorSafer,
+		PodMonitorSafer:     config.PodMonitorSafer,
+		ProbeSafer:          config.ProbeSafer,
+		Logger:              logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	mux := http.NewServeMux()
 
 	h := handler{
 		handler:          mux,
-		marker:           config.Marker,
+		mutator:          mt,
 		ingRegexHostVal:  config.IngressRegexHostValidator,
 		ingSingleHostVal: config.IngressSingleHostValidator,
-		servMonSafer:     config.ServiceMonitorSafer,
+		ingHostPolicyVal: config.IngressHostPolicyValidator,
+		ingNSHostPolVal:  config.IngressNSHostPolicyValidator,
+		ingHostUniqVal:   config.IngressHostUniquenessValidator,
+		ingTLSVal:        config.IngressTLSValidator,
+		ingBackendVal:    config.IngressBackendValidator,
+		ingClassVal:      config.IngressClassValidator,
+		ingAnnotVal:      config.IngressAnnotationValidator,
+		ingAllErrors:     config.IngressValidationAllErrors,
+		ingValModes:      config.IngressValidatorModes,
+		servMonVal:       config.ServiceMonitorValidator,
+		promRuleVal:      config.PrometheusRuleValidator,
 		metrics:          config.MetricsRecorder,
-		logger:           config.Logger.WithKV(log.KV{"service": "webhook-handler"}),
+		logger:           logger,
 	}
 
 	// Register all the routes with our router.
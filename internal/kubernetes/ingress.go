@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/slok/k8s-webhook-example/internal/log"
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+// IngressWatcher watches the ingresses of the cluster using an informer.
+type IngressWatcher struct {
+	cli    kubernetesclient.Interface
+	logger log.Logger
+}
+
+// NewIngressWatcher returns a new IngressWatcher.
+func NewIngressWatcher(cli kubernetesclient.Interface, logger log.Logger) IngressWatcher {
+	return IngressWatcher{
+		cli:    cli,
+		logger: logger.WithKV(log.KV{"service": "ingress-watcher"}),
+	}
+}
+
+// Watch satisfies ingress.IngressWatcher interface.
+func (i IngressWatcher) Watch(ctx context.Context, h ingress.IngressEventHandler) error {
+	factory := informers.NewSharedInformerFactory(i.cli, resyncPeriod)
+	informer := factory.Networking().V1().Ingresses().Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if o, ok := obj.(metav1.Object); ok {
+				h.AddOrUpdateIngress(o)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if o, ok := newObj.(metav1.Object); ok {
+				h.AddOrUpdateIngress(o)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			// We could miss the delete event, in that case we receive the last known state.
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+
+			if o, ok := obj.(metav1.Object); ok {
+				h.DeleteIngress(o)
+			}
+		},
+	})
+	factory.Start(ctx.Done())
+
+	// Handler events are processed asynchronously, so the handler could receive some of the
+	// existing ingresses right after the sync.
+	i.logger.Infof("waiting for ingress cache to be synced...")
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("could not sync ingress cache")
+	}
+
+	return nil
+}
+
+var _ ingress.IngressWatcher = IngressWatcher{}
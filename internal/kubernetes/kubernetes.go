@@ -0,0 +1,37 @@
+package kubernetes
+
+import (
+	"fmt"
+	"time"
+
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const resyncPeriod = 10 * time.Minute
+
+// NewClient returns a new Kubernetes client. If the kubeconfig path is empty it will
+// use the in-cluster configuration.
+func NewClient(kubeConfigPath string) (kubernetesclient.Interface, error) {
+	var (
+		cfg *rest.Config
+		err error
+	)
+
+	if kubeConfigPath == "" {
+		cfg, err = rest.InClusterConfig()
+	} else {
+		cfg, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load Kubernetes configuration: %w", err)
+	}
+
+	cli, err := kubernetesclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not create Kubernetes client: %w", err)
+	}
+
+	return cli, nil
+}
@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/informers"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/slok/k8s-webhook-example/internal/log"
+)
+
+// NamespaceCache is a namespace repository that serves the namespaces from an in-memory
+// cache kept in sync with the Kubernetes API using an informer.
+type NamespaceCache struct {
+	lister corev1listers.NamespaceLister
+}
+
+// NewNamespaceCache returns a new NamespaceCache, it will start the informer and wait until
+// the cache is synced. The informer will stop when the context is done.
+func NewNamespaceCache(ctx context.Context, cli kubernetesclient.Interface, logger log.Logger) (*NamespaceCache, error) {
+	factory := informers.NewSharedInformerFactory(cli, resyncPeriod)
+	informer := factory.Core().V1().Namespaces()
+
+	// Register the informer on the factory before starting.
+	lister := informer.Lister()
+	hasSynced := informer.Informer().HasSynced
+	factory.Start(ctx.Done())
+
+	logger.WithKV(log.KV{"service": "namespace-cache"}).Infof("waiting for namespace cache to be synced...")
+	if !cache.WaitForCacheSync(ctx.Done(), hasSynced) {
+		return nil, fmt.Errorf("could not sync namespace cache")
+	}
+
+	return &NamespaceCache{lister: lister}, nil
+}
+
+// GetNamespaceLabels returns the labels of a namespace.
+func (n *NamespaceCache) GetNamespaceLabels(_ context.Context, namespace string) (map[string]string, error) {
+	ns, err := n.lister.Get(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not get namespace: %w", err)
+	}
+
+	return ns.Labels, nil
+}
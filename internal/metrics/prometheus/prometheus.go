@@ -1,14 +1,21 @@
 package prometheus
 
 import (
+	"context"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	gohttpmetrics "github.com/slok/go-http-metrics/metrics"
 	gohttpmetricsprometheus "github.com/slok/go-http-metrics/metrics/prometheus"
 	whprometheus "github.com/slok/kubewebhook/v2/pkg/metrics/prometheus"
 
+	"github.com/slok/k8s-webhook-example/internal/certificate"
+	"github.com/slok/k8s-webhook-example/internal/exemption"
 	"github.com/slok/k8s-webhook-example/internal/http/webhook"
 )
 
+const prefix = "k8s_webhook_example"
+
 // Types used to avoid collisions with the same interface naming.
 type httpRecorder = gohttpmetrics.Recorder
 type webhookRecorder = whprometheus.Recorder
@@ -17,6 +24,10 @@ type webhookRecorder = whprometheus.Recorder
 type Recorder struct {
 	httpRecorder
 	webhookRecorder
+
+	tlsCertExpiry               prometheus.Gauge
+	validationNotEnforcedDenial *prometheus.CounterVec
+	exemptedRequests            *prometheus.CounterVec
 }
 
 // NewRecorder returns a new Prometheus Recorder.
@@ -24,11 +35,56 @@ func NewRecorder(reg prometheus.Registerer) Recorder {
 	// TODO error,
 	rec, _ := whprometheus.NewRecorder(whprometheus.RecorderConfig{Registry: reg})
 
-	return Recorder{
+	r := Recorder{
 		httpRecorder:    gohttpmetricsprometheus.NewRecorder(gohttpmetricsprometheus.Config{Registry: reg}),
 		webhookRecorder: *rec,
+
+		tlsCertExpiry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: prefix,
+			Subsystem: "tls",
+			Name:      "certificate_expiration_timestamp_seconds",
+			Help:      "The expiration time of the TLS certificate used by the webhooks server.",
+		}),
+
+		validationNotEnforcedDenial: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Subsystem: "validation",
+			Name:      "not_enforced_denials_total",
+			Help:      "The total number of denials that have not been enforced because the validation is in warn or audit mode.",
+		}, []string{"webhook_id", "validator", "resource_namespace", "mode"}),
+
+		exemptedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prefix,
+			Subsystem: "exemption",
+			Name:      "skipped_requests_total",
+			Help:      "The total number of requests skipped by the webhooks because the object was exempted.",
+		}, []string{"webhook_id", "reason"}),
 	}
+
+	reg.MustRegister(
+		r.tlsCertExpiry,
+		r.validationNotEnforcedDenial,
+		r.exemptedRequests,
+	)
+
+	return r
+}
+
+// SetTLSCertificateExpiry satisfies certificate.MetricsRecorder interface.
+func (r Recorder) SetTLSCertificateExpiry(_ context.Context, expiry time.Time) {
+	r.tlsCertExpiry.Set(float64(expiry.Unix()))
+}
+
+// IncValidationNotEnforcedDenial satisfies webhook.MetricsRecorder interface.
+func (r Recorder) IncValidationNotEnforcedDenial(_ context.Context, webhookID, validator, namespace string, mode webhook.ValidationMode) {
+	r.validationNotEnforcedDenial.WithLabelValues(webhookID, validator, namespace, string(mode)).Inc()
+}
+
+// IncExemptedRequest satisfies webhook.MetricsRecorder interface.
+func (r Recorder) IncExemptedRequest(_ context.Context, webhookID string, reason exemption.Reason) {
+	r.exemptedRequests.WithLabelValues(webhookID, string(reason)).Inc()
 }
 
 // Interface assertion.
 var _ webhook.MetricsRecorder = Recorder{}
+var _ certificate.MetricsRecorder = Recorder{}
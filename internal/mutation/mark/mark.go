@@ -2,41 +2,202 @@ package mark
 
 import (
 	"context"
+	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Result is the result of a marking.
+type Result struct {
+	// Warnings are the messages the user should know about the marking (e.g: removed labels).
+	Warnings []string
+}
+
 // Marker knows how to mark Kubernetes resources.
 type Marker interface {
-	Mark(ctx context.Context, obj metav1.Object) error
+	Mark(ctx context.Context, obj metav1.Object) (*Result, error)
+}
+
+// MarkerConfig is the configuration of the label and annotation markers.
+type MarkerConfig struct {
+	// Marks are the keys and values of the marks, the values can be Go templates.
+	Marks map[string]string
+	// Modes are the modes of the marks by key, by default `MarkModeOverwrite` for the static
+	// marks and `MarkModeSetIfAbsent` for the templated marks, so these are rendered only once.
+	Modes map[string]MarkMode
+	// PodTemplates will propagate the marks to the pod template of the workloads (deployments,
+	// daemonsets, statefulsets, jobs and cronjobs).
+	PodTemplates bool
+	// NamespaceLabelsGetter is used to get the namespace labels of the templates (e.g:
+	// `{{ index .NamespaceLabels "team" }}`), if missing the namespace labels will be empty.
+	NamespaceLabelsGetter NamespaceLabelsGetter
 }
 
-// NewLabelMarker returns a new marker that will mark with labels.
-func NewLabelMarker(marks map[string]string) Marker {
-	return labelmarker{marks: marks}
+// NewLabelMarker returns a new marker that will mark with labels. The label values can be
+// Go templates that will be rendered per object (e.g: `{{ .Namespace }}`, `{{ .Kind }}`,
+// `{{ .Name }}`, `{{ .Username }}`, `{{ .CreationTimestamp.Unix }}`, `{{ .Labels.team }}` or
+// `{{ index .NamespaceLabels "team" }}`),
+// the rendered values will be sanitized to be valid label values.
+// The marks are set based on their mode, by default the static marks will overwrite the existing
+// values and the templated marks will only be set when missing.
+// The marks with `MarkModeRejectIfDifferent` mode will return `ErrMarkRejected` error when the
+// object has a different value.
+func NewLabelMarker(config MarkerConfig) (Marker, error) {
+	ms, err := newMarkValues(config.Marks, config.Modes)
+	if err != nil {
+		return nil, err
+	}
+
+	// The namespace labels are only required by the templates.
+	var nsGetter NamespaceLabelsGetter
+	if templated(ms) {
+		nsGetter = config.NamespaceLabelsGetter
+	}
+
+	return labelmarker{marks: ms, podTemplates: config.PodTemplates, nsGetter: nsGetter}, nil
 }
 
 type labelmarker struct {
-	marks map[string]string
+	marks        map[string]markValue
+	podTemplates bool
+	nsGetter     NamespaceLabelsGetter
 }
 
-func (l labelmarker) Mark(_ context.Context, obj metav1.Object) error {
+func (l labelmarker) Mark(ctx context.Context, obj metav1.Object) (*Result, error) {
+	data, err := newTemplateData(ctx, obj, l.nsGetter)
+	if err != nil {
+		return nil, err
+	}
+	render := func(mv markValue) (string, error) { return mv.renderLabel(data) }
+
+	err = l.mark(obj, render)
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.podTemplates {
+		return &Result{}, nil
+	}
+
+	tplMeta := getPodTemplateMeta(obj)
+	if tplMeta == nil {
+		return &Result{}, nil
+	}
+
+	err = l.mark(tplMeta, render)
+	if err != nil {
+		return nil, fmt.Errorf("could not mark pod template: %w", err)
+	}
+
+	return &Result{}, nil
+}
+
+func (l labelmarker) mark(obj metav1.Object, render func(markValue) (string, error)) error {
 	labels := obj.GetLabels()
 	if labels == nil {
 		labels = map[string]string{}
 	}
 
-	for k, v := range l.marks {
-		labels[k] = v
+	err := setMarks("label", labels, l.marks, render)
+	if err != nil {
+		return err
 	}
 
 	obj.SetLabels(labels)
 	return nil
 }
 
+// NewAnnotationMarker returns a new marker that will mark with annotations. The annotation
+// values can be Go templates and use modes like the label marker ones.
+func NewAnnotationMarker(config MarkerConfig) (Marker, error) {
+	ms, err := newMarkValues(config.Marks, config.Modes)
+	if err != nil {
+		return nil, err
+	}
+
+	// The namespace labels are only required by the templates.
+	var nsGetter NamespaceLabelsGetter
+	if templated(ms) {
+		nsGetter = config.NamespaceLabelsGetter
+	}
+
+	return annotationmarker{marks: ms, podTemplates: config.PodTemplates, nsGetter: nsGetter}, nil
+}
+
+type annotationmarker struct {
+	marks        map[string]markValue
+	podTemplates bool
+	nsGetter     NamespaceLabelsGetter
+}
+
+func (a annotationmarker) Mark(ctx context.Context, obj metav1.Object) (*Result, error) {
+	data, err := newTemplateData(ctx, obj, a.nsGetter)
+	if err != nil {
+		return nil, err
+	}
+	render := func(mv markValue) (string, error) { return mv.render(data) }
+
+	err = a.mark(obj, render)
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.podTemplates {
+		return &Result{}, nil
+	}
+
+	tplMeta := getPodTemplateMeta(obj)
+	if tplMeta == nil {
+		return &Result{}, nil
+	}
+
+	err = a.mark(tplMeta, render)
+	if err != nil {
+		return nil, fmt.Errorf("could not mark pod template: %w", err)
+	}
+
+	return &Result{}, nil
+}
+
+func (a annotationmarker) mark(obj metav1.Object, render func(markValue) (string, error)) error {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	err := setMarks("annotation", annotations, a.marks, render)
+	if err != nil {
+		return err
+	}
+
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// NewChain returns a marker that will mark using all the markers in order, if any of the
+// markers fails the chain will stop. The results of all the markers will be aggregated.
+func NewChain(markers ...Marker) Marker {
+	return chain(markers)
+}
+
+type chain []Marker
+
+func (c chain) Mark(ctx context.Context, obj metav1.Object) (*Result, error) {
+	res := &Result{}
+	for _, m := range c {
+		r, err := m.Mark(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		res.Warnings = append(res.Warnings, r.Warnings...)
+	}
+
+	return res, nil
+}
+
 // DummyMarker is a marker that doesn't do anything.
 var DummyMarker Marker = dummyMaker(0)
 
 type dummyMaker int
 
-func (dummyMaker) Mark(_ context.Context, _ metav1.Object) error { return nil }
+func (dummyMaker) Mark(_ context.Context, _ metav1.Object) (*Result, error) { return &Result{}, nil }
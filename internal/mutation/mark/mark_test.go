@@ -2,12 +2,17 @@ package mark_test
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
 )
@@ -71,9 +76,580 @@ func TestLabelMarkerMark(t *testing.T) {
 			assert := assert.New(t)
 			require := require.New(t)
 
-			m := mark.NewLabelMarker(test.marks)
+			m, err := mark.NewLabelMarker(mark.MarkerConfig{Marks: test.marks})
+			require.NoError(err)
+
+			_, err = m.Mark(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
+
+func TestLabelMarkerMarkTemplates(t *testing.T) {
+	creation := metav1.NewTime(time.Date(2021, 8, 13, 6, 28, 14, 0, time.UTC))
+
+	tests := map[string]struct {
+		marks    map[string]string
+		modes    map[string]mark.MarkMode
+		username string
+		obj      metav1.Object
+		expObj   metav1.Object
+		expErr   bool
+	}{
+		"Having templated marks, the labels should be rendered with the object and request data.": {
+			marks: map[string]string{
+				"static":     "value1",
+				"namespace":  "{{ .Namespace }}",
+				"kind":       "{{ .Kind }}",
+				"name":       "{{ .Name }}",
+				"created-by": "{{ .Username }}",
+				"created-at": "{{ .CreationTimestamp.Format \"2006-01-02\" }}",
+				"owner":      "{{ .Labels.team }}-{{ .Namespace }}",
+			},
+			username: "system:serviceaccount:test-ns:deployer",
+			obj: &corev1.Pod{
+				TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test",
+					Namespace:         "test-ns",
+					CreationTimestamp: creation,
+					Labels:            map[string]string{"team": "team-a"},
+				},
+			},
+			expObj: &corev1.Pod{
+				TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "test",
+					Namespace:         "test-ns",
+					CreationTimestamp: creation,
+					Labels: map[string]string{
+						"team":       "team-a",
+						"static":     "value1",
+						"namespace":  "test-ns",
+						"kind":       "Pod",
+						"name":       "test",
+						"created-by": "system-serviceaccount-test-ns-deployer",
+						"created-at": "2021-08-13",
+						"owner":      "team-a-test-ns",
+					},
+				},
+			},
+		},
+
+		"Having templated marks with missing data, the labels should be rendered as valid label values.": {
+			marks: map[string]string{
+				"owner":      "{{ .Labels.team }}",
+				"created-by": "{{ .Username }}",
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Labels: map[string]string{
+						"owner":      "",
+						"created-by": "",
+					},
+				},
+			},
+		},
+
+		"Having a templated mark with a long value, the label should be truncated to a valid label value.": {
+			marks: map[string]string{
+				"name": "{{ .Name }}",
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-" + strings.Repeat("a", 57) + "-test"},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-" + strings.Repeat("a", 57) + "-test",
+					Labels: map[string]string{"name": "test-" + strings.Repeat("a", 57)},
+				},
+			},
+		},
+
+		"Having templated marks on an already marked object, the labels should not be rendered again by default.": {
+			marks: map[string]string{
+				"static":     "value1",
+				"created-by": "{{ .Username }}",
+			},
+			username: "bob",
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"static": "value0", "created-by": "alice"},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"static": "value1", "created-by": "alice"},
+				},
+			},
+		},
+
+		"Having templated marks in overwrite mode on an already marked object, the labels should be rendered again.": {
+			marks: map[string]string{
+				"modified-by": "{{ .Username }}",
+			},
+			modes:    map[string]mark.MarkMode{"modified-by": mark.MarkModeOverwrite},
+			username: "bob",
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"modified-by": "alice"},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{"modified-by": "bob"},
+				},
+			},
+		},
+
+		"Having an object being created with generate name and without creation timestamp, the name should be the generate name and the creation truncated to seconds.": {
+			marks: map[string]string{
+				"name":                "{{ .Name }}",
+				"created-at-fraction": "{{ .CreationTimestamp.Nanosecond }}",
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: "test-"},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-",
+					Labels:       map[string]string{"name": "test", "created-at-fraction": "0"},
+				},
+			},
+		},
+
+		"Having a template that fails on render, it should fail.": {
+			marks: map[string]string{
+				"test": "{{ index .Labels 1 }}",
+			},
+			obj:    &corev1.Pod{},
+			expErr: true,
+		},
+
+		"Having a template with namespace labels, the labels should be rendered with the namespace labels.": {
+			marks: map[string]string{
+				"team": `{{ index .NamespaceLabels "team" }}`,
+			},
+			obj:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns"}},
+			expObj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Labels: map[string]string{"team": "team-a"}}},
+		},
+
+		"Having a template with namespace labels on a missing namespace, the namespace labels should be empty.": {
+			marks: map[string]string{
+				"team": `{{ index .NamespaceLabels "team" }}`,
+			},
+			obj:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "missing"}},
+			expObj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "missing", Labels: map[string]string{"team": ""}}},
+		},
+
+		"Having a template with namespace labels and an error getting the namespace labels, it should fail.": {
+			marks: map[string]string{
+				"team": `{{ index .NamespaceLabels "team" }}`,
+			},
+			obj:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "error"}},
+			expErr: true,
+		},
+	}
+
+	nsGetter := testNamespaceLabelsGetter{"test-ns": {"team": "team-a"}}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mark.NewLabelMarker(mark.MarkerConfig{Marks: test.marks, Modes: test.modes, NamespaceLabelsGetter: nsGetter})
+			require.NoError(err)
+
+			ctx := mark.ContextWithUsername(context.TODO(), test.username)
+			_, err = m.Mark(ctx, test.obj)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expObj, test.obj)
+			}
+		})
+	}
+}
+
+func TestNewLabelMarkerInvalidTemplate(t *testing.T) {
+	_, err := mark.NewLabelMarker(mark.MarkerConfig{Marks: map[string]string{"test": "{{ .Namespace "}})
+	assert.Error(t, err)
+}
+
+func TestAnnotationMarkerMark(t *testing.T) {
+	tests := map[string]struct {
+		marks    map[string]string
+		username string
+		obj      metav1.Object
+		expObj   metav1.Object
+	}{
+		"Having a pod, the annotations should be mutated.": {
+			marks: map[string]string{
+				"test1": "value1",
+				"test2": "value2",
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						"test2": "old-value2",
+						"test3": "value3",
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						"test1": "value1",
+						"test2": "value2",
+						"test3": "value3",
+					},
+				},
+			},
+		},
+
+		"Having templated marks, the annotations should be rendered without label value sanitization.": {
+			marks: map[string]string{
+				"created-by": "{{ .Username }}",
+			},
+			username: "system:serviceaccount:test-ns:deployer",
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			},
+			expObj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{"created-by": "system:serviceaccount:test-ns:deployer"},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mark.NewAnnotationMarker(mark.MarkerConfig{Marks: test.marks})
+			require.NoError(err)
+
+			ctx := mark.ContextWithUsername(context.TODO(), test.username)
+			_, err = m.Mark(ctx, test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+		})
+	}
+}
+
+func TestChainMark(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	lm, err := mark.NewLabelMarker(mark.MarkerConfig{Marks: map[string]string{"test1": "value1"}})
+	require.NoError(err)
+	am, err := mark.NewAnnotationMarker(mark.MarkerConfig{Marks: map[string]string{"test2": "value2"}})
+	require.NoError(err)
+
+	obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	_, err = mark.NewChain(lm, am).Mark(context.TODO(), obj)
+	require.NoError(err)
+
+	expObj := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Labels:      map[string]string{"test1": "value1"},
+			Annotations: map[string]string{"test2": "value2"},
+		},
+	}
+	assert.Equal(expObj, obj)
+}
+
+func TestLabelMarkerMarkModes(t *testing.T) {
+	marks := map[string]string{
+		"overwrite":           "value1",
+		"set-if-absent":       "value2",
+		"reject-if-different": "value3",
+	}
+	modes := map[string]mark.MarkMode{
+		"overwrite":           mark.MarkModeOverwrite,
+		"set-if-absent":       mark.MarkModeSetIfAbsent,
+		"reject-if-different": mark.MarkModeRejectIfDifferent,
+	}
+
+	tests := map[string]struct {
+		labels    map[string]string
+		expLabels map[string]string
+		expErr    error
+	}{
+		"Having an object without the labels, all the labels should be set.": {
+			expLabels: map[string]string{
+				"overwrite":           "value1",
+				"set-if-absent":       "value2",
+				"reject-if-different": "value3",
+			},
+		},
+
+		"Having an object with the same labels, the labels should be kept.": {
+			labels: map[string]string{
+				"overwrite":           "value1",
+				"set-if-absent":       "value2",
+				"reject-if-different": "value3",
+			},
+			expLabels: map[string]string{
+				"overwrite":           "value1",
+				"set-if-absent":       "value2",
+				"reject-if-different": "value3",
+			},
+		},
+
+		"Having an object with different labels, the overwrite labels should be replaced and the set if absent kept.": {
+			labels: map[string]string{
+				"overwrite":     "old-value1",
+				"set-if-absent": "old-value2",
+			},
+			expLabels: map[string]string{
+				"overwrite":           "value1",
+				"set-if-absent":       "old-value2",
+				"reject-if-different": "value3",
+			},
+		},
+
+		"Having an object with a different reject if different label, it should be rejected.": {
+			labels: map[string]string{
+				"reject-if-different": "old-value3",
+			},
+			expErr: mark.ErrMarkRejected,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mark.NewLabelMarker(mark.MarkerConfig{Marks: marks, Modes: modes})
+			require.NoError(err)
+
+			obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: test.labels}}
+			_, err = m.Mark(context.TODO(), obj)
+
+			if test.expErr != nil {
+				assert.ErrorIs(err, test.expErr)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expLabels, obj.Labels)
+			}
+		})
+	}
+}
+
+func TestAnnotationMarkerMarkRejectIfDifferent(t *testing.T) {
+	m, err := mark.NewAnnotationMarker(mark.MarkerConfig{
+		Marks: map[string]string{"test": "value1"},
+		Modes: map[string]mark.MarkMode{"test": mark.MarkModeRejectIfDifferent},
+	})
+	require.NoError(t, err)
+
+	obj := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"test": "value2"}}}
+	_, err = m.Mark(context.TODO(), obj)
+	assert.ErrorIs(t, err, mark.ErrMarkRejected)
+}
+
+func TestNewLabelMarkerInvalidMode(t *testing.T) {
+	_, err := mark.NewLabelMarker(mark.MarkerConfig{
+		Marks: map[string]string{"test": "value1"},
+		Modes: map[string]mark.MarkMode{"test": "wrong"},
+	})
+	assert.Error(t, err)
+}
+
+func TestMarkersPodTemplates(t *testing.T) {
+	tests := map[string]struct {
+		podTemplates bool
+		obj          metav1.Object
+		expObj       metav1.Object
+	}{
+		"Having a deployment without pod template propagation, the pod template should not be marked.": {
+			obj: &appsv1.Deployment{},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"test1": "value1"},
+					Annotations: map[string]string{"test2": "value2"},
+				},
+			},
+		},
+
+		"Having a deployment, the pod template should be marked.": {
+			podTemplates: true,
+			obj: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test"}},
+					},
+				},
+			},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"test1": "value1"},
+					Annotations: map[string]string{"test2": "value2"},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels:      map[string]string{"app": "test", "test1": "value1"},
+							Annotations: map[string]string{"test2": "value2"},
+						},
+					},
+				},
+			},
+		},
+
+		"Having a cronjob, the job pod template should be marked.": {
+			podTemplates: true,
+			obj:          &batchv1.CronJob{},
+			expObj: &batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"test1": "value1"},
+					Annotations: map[string]string{"test2": "value2"},
+				},
+				Spec: batchv1.CronJobSpec{
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								ObjectMeta: metav1.ObjectMeta{
+									Labels:      map[string]string{"test1": "value1"},
+									Annotations: map[string]string{"test2": "value2"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"Having a pod, only the pod should be marked.": {
+			podTemplates: true,
+			obj:          &corev1.Pod{},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"test1": "value1"},
+					Annotations: map[string]string{"test2": "value2"},
+				},
+			},
+		},
+
+		"Having an unstructured statefulset, the pod template should be marked.": {
+			podTemplates: true,
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "StatefulSet",
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{"app": "test"},
+						},
+					},
+				},
+			}},
+			expObj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "StatefulSet",
+				"metadata": map[string]interface{}{
+					"labels":      map[string]interface{}{"test1": "value1"},
+					"annotations": map[string]interface{}{"test2": "value2"},
+				},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"labels":      map[string]interface{}{"app": "test", "test1": "value1"},
+							"annotations": map[string]interface{}{"test2": "value2"},
+						},
+					},
+				},
+			}},
+		},
+
+		"Having an unstructured cronjob, the job pod template should be marked.": {
+			podTemplates: true,
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "batch/v1beta1",
+				"kind":       "CronJob",
+				"spec": map[string]interface{}{
+					"jobTemplate": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"template": map[string]interface{}{},
+						},
+					},
+				},
+			}},
+			expObj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "batch/v1beta1",
+				"kind":       "CronJob",
+				"metadata": map[string]interface{}{
+					"labels":      map[string]interface{}{"test1": "value1"},
+					"annotations": map[string]interface{}{"test2": "value2"},
+				},
+				"spec": map[string]interface{}{
+					"jobTemplate": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"template": map[string]interface{}{
+								"metadata": map[string]interface{}{
+									"labels":      map[string]interface{}{"test1": "value1"},
+									"annotations": map[string]interface{}{"test2": "value2"},
+								},
+							},
+						},
+					},
+				},
+			}},
+		},
+
+		"Having an unstructured deployment without pod template, only the deployment should be marked.": {
+			podTemplates: true,
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+			}},
+			expObj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"labels":      map[string]interface{}{"test1": "value1"},
+					"annotations": map[string]interface{}{"test2": "value2"},
+				},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			lm, err := mark.NewLabelMarker(mark.MarkerConfig{
+				Marks:        map[string]string{"test1": "value1"},
+				PodTemplates: test.podTemplates,
+			})
+			require.NoError(err)
+			am, err := mark.NewAnnotationMarker(mark.MarkerConfig{
+				Marks:        map[string]string{"test2": "value2"},
+				PodTemplates: test.podTemplates,
+			})
+			require.NoError(err)
 
-			err := m.Mark(context.TODO(), test.obj)
+			_, err = mark.NewChain(lm, am).Mark(context.TODO(), test.obj)
 			require.NoError(err)
 
 			assert.Equal(test.expObj, test.obj)
@@ -0,0 +1,62 @@
+package mark
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrMarkRejected will be returned when an object can't be marked because of the mark mode.
+var ErrMarkRejected = errors.New("mark rejected")
+
+// MarkMode is the mode a mark is set on the objects.
+type MarkMode string
+
+const (
+	// MarkModeOverwrite will set the mark value replacing any existing value.
+	MarkModeOverwrite MarkMode = "overwrite"
+	// MarkModeSetIfAbsent will set the mark value only when the object doesn't have it.
+	MarkModeSetIfAbsent MarkMode = "set-if-absent"
+	// MarkModeRejectIfDifferent will set the mark value when the object doesn't have it, and
+	// reject the object when it has a different value.
+	MarkModeRejectIfDifferent MarkMode = "reject-if-different"
+)
+
+func (m MarkMode) valid() bool {
+	switch m {
+	case MarkModeOverwrite, MarkModeSetIfAbsent, MarkModeRejectIfDifferent:
+		return true
+	}
+	return false
+}
+
+// setMarks sets the marks on the current marks (labels or annotations) based on the mark modes.
+// The marks are set in order so the rejections are deterministic.
+func setMarks(markType string, current map[string]string, marks map[string]markValue, render func(markValue) (string, error)) error {
+	keys := make([]string, 0, len(marks))
+	for k := range marks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		mv := marks[k]
+		v, err := render(mv)
+		if err != nil {
+			return err
+		}
+
+		currentV, ok := current[k]
+		switch {
+		case !ok:
+			current[k] = v
+		case mv.mode == MarkModeSetIfAbsent:
+		case mv.mode == MarkModeRejectIfDifferent && currentV != v:
+			return fmt.Errorf("%w: %s %q value %q is different from the required %q", ErrMarkRejected, markType, k, currentV, v)
+		default:
+			current[k] = v
+		}
+	}
+
+	return nil
+}
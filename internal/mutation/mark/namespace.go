@@ -0,0 +1,104 @@
+package mark
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceLabelsGetter knows how to get the labels of a namespace.
+type NamespaceLabelsGetter interface {
+	GetNamespaceLabels(ctx context.Context, namespace string) (map[string]string, error)
+}
+
+// NamespaceLabelsMarkerConfig is the configuration of the namespace labels marker.
+type NamespaceLabelsMarkerConfig struct {
+	// Labels are the namespace label keys that will be copied to the objects.
+	Labels []string
+	// Modes are the modes of the marks by label key, by default `MarkModeOverwrite`.
+	Modes map[string]MarkMode
+	// PodTemplates will propagate the marks to the pod template of the workloads.
+	PodTemplates bool
+	// NamespaceLabelsGetter is used to get the namespace labels, normally a cache.
+	NamespaceLabelsGetter NamespaceLabelsGetter
+}
+
+func (c *NamespaceLabelsMarkerConfig) defaults() error {
+	if c.NamespaceLabelsGetter == nil {
+		return fmt.Errorf("namespace labels getter is required")
+	}
+
+	for _, l := range c.Labels {
+		mode, ok := c.Modes[l]
+		if ok && !mode.valid() {
+			return fmt.Errorf("invalid %q mark mode: %q", l, mode)
+		}
+	}
+
+	return nil
+}
+
+// NewNamespaceLabelsMarker returns a new marker that copies the selected labels of the object
+// namespace into the object, e.g: the `team` label of the namespace.
+// The cluster scoped objects, the missing namespaces and the namespace labels that are missing
+// will be ignored, returning a warning in case of a missing namespace.
+func NewNamespaceLabelsMarker(config NamespaceLabelsMarkerConfig) (Marker, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	modes := make(map[string]MarkMode, len(config.Labels))
+	for _, l := range config.Labels {
+		mode := config.Modes[l]
+		if mode == "" {
+			mode = MarkModeOverwrite
+		}
+		modes[l] = mode
+	}
+
+	return namespaceLabelsMarker{
+		modes:        modes,
+		podTemplates: config.PodTemplates,
+		nsGetter:     config.NamespaceLabelsGetter,
+	}, nil
+}
+
+type namespaceLabelsMarker struct {
+	// modes are the modes by namespace label key.
+	modes        map[string]MarkMode
+	podTemplates bool
+	nsGetter     NamespaceLabelsGetter
+}
+
+func (n namespaceLabelsMarker) Mark(ctx context.Context, obj metav1.Object) (*Result, error) {
+	ns := obj.GetNamespace()
+	if ns == "" {
+		return &Result{}, nil
+	}
+
+	nsLabels, err := n.nsGetter.GetNamespaceLabels(ctx, ns)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return &Result{Warnings: []string{fmt.Sprintf("namespace %q labels could not be copied: %s", ns, err)}}, nil
+		}
+		return nil, fmt.Errorf("could not get namespace %q labels: %w", ns, err)
+	}
+
+	// Namespace label values are already valid label values, the values are not templated.
+	marks := map[string]markValue{}
+	for l, mode := range n.modes {
+		v, ok := nsLabels[l]
+		if ok {
+			marks[l] = markValue{value: v, mode: mode}
+		}
+	}
+
+	if len(marks) == 0 {
+		return &Result{}, nil
+	}
+
+	return labelmarker{marks: marks, podTemplates: n.podTemplates}.Mark(ctx, obj)
+}
@@ -0,0 +1,169 @@
+package mark_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
+)
+
+var errTestNamespace = errors.New("something went wrong")
+
+type testNamespaceLabelsGetter map[string]map[string]string
+
+func (t testNamespaceLabelsGetter) GetNamespaceLabels(_ context.Context, namespace string) (map[string]string, error) {
+	if namespace == "error" {
+		return nil, errTestNamespace
+	}
+
+	l, ok := t[namespace]
+	if !ok {
+		return nil, apierrors.NewNotFound(corev1.Resource("namespaces"), namespace)
+	}
+	return l, nil
+}
+
+func TestNamespaceLabelsMarkerMark(t *testing.T) {
+	nsGetter := testNamespaceLabelsGetter{
+		"test-ns": {
+			"team":        "team-a",
+			"cost-center": "1234",
+			"other":       "value",
+		},
+	}
+
+	tests := map[string]struct {
+		config      mark.NamespaceLabelsMarkerConfig
+		obj         metav1.Object
+		expObj      metav1.Object
+		expWarnings []string
+		expErr      error
+	}{
+		"Having an object, the selected namespace labels should be copied.": {
+			config: mark.NamespaceLabelsMarkerConfig{Labels: []string{"team", "cost-center", "missing"}},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-ns",
+					Labels:    map[string]string{"app": "test", "team": "team-b"},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-ns",
+					Labels: map[string]string{
+						"app":         "test",
+						"team":        "team-a",
+						"cost-center": "1234",
+					},
+				},
+			},
+		},
+
+		"Having an object with set if absent mode, the existing labels should be kept.": {
+			config: mark.NamespaceLabelsMarkerConfig{
+				Labels: []string{"team", "cost-center"},
+				Modes:  map[string]mark.MarkMode{"team": mark.MarkModeSetIfAbsent},
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-ns",
+					Labels:    map[string]string{"team": "team-b"},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-ns",
+					Labels: map[string]string{
+						"team":        "team-b",
+						"cost-center": "1234",
+					},
+				},
+			},
+		},
+
+		"Having an object with reject if different mode and a different label, it should be rejected.": {
+			config: mark.NamespaceLabelsMarkerConfig{
+				Labels: []string{"team"},
+				Modes:  map[string]mark.MarkMode{"team": mark.MarkModeRejectIfDifferent},
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test-ns",
+					Labels:    map[string]string{"team": "team-b"},
+				},
+			},
+			expErr: mark.ErrMarkRejected,
+		},
+
+		"Having a cluster scoped object, it should not be mutated.": {
+			config: mark.NamespaceLabelsMarkerConfig{Labels: []string{"team"}},
+			obj:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}},
+			expObj: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}},
+		},
+
+		"Having an object on a missing namespace, it should not be mutated and warn.": {
+			config:      mark.NamespaceLabelsMarkerConfig{Labels: []string{"team"}},
+			obj:         &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "missing"}},
+			expObj:      &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "missing"}},
+			expWarnings: []string{`namespace "missing" labels could not be copied: namespaces "missing" not found`},
+		},
+
+		"Having an error while getting the namespace labels, it should return an error.": {
+			config: mark.NamespaceLabelsMarkerConfig{Labels: []string{"team"}},
+			obj:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "error"}},
+			expErr: errTestNamespace,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			test.config.NamespaceLabelsGetter = nsGetter
+			m, err := mark.NewNamespaceLabelsMarker(test.config)
+			require.NoError(err)
+
+			res, err := m.Mark(context.TODO(), test.obj)
+
+			if test.expErr != nil {
+				assert.ErrorIs(err, test.expErr)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expObj, test.obj)
+				assert.Equal(test.expWarnings, res.Warnings)
+			}
+		})
+	}
+}
+
+func TestNewNamespaceLabelsMarkerInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		config mark.NamespaceLabelsMarkerConfig
+	}{
+		"Missing namespace labels getter should be invalid.": {
+			config: mark.NamespaceLabelsMarkerConfig{Labels: []string{"team"}},
+		},
+
+		"An invalid mode should be invalid.": {
+			config: mark.NamespaceLabelsMarkerConfig{
+				Labels:                []string{"team"},
+				Modes:                 map[string]mark.MarkMode{"team": "wrong"},
+				NamespaceLabelsGetter: testNamespaceLabelsGetter{},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := mark.NewNamespaceLabelsMarker(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
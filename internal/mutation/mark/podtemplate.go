@@ -0,0 +1,75 @@
+package mark
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// podTemplateFields are the paths to the pod template of the unstructured workloads by kind.
+var podTemplateFields = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+// getPodTemplateMeta returns the metadata of the workload pod template (e.g: deployments
+// `spec.template.metadata` or cronjobs `spec.jobTemplate.spec.template.metadata`), the returned
+// metadata can be mutated. If the object is not a workload or doesn't have a pod template, it
+// will return nil.
+func getPodTemplateMeta(obj metav1.Object) metav1.Object {
+	switch v := obj.(type) {
+	case *appsv1.Deployment:
+		return &v.Spec.Template.ObjectMeta
+	case *appsv1.DaemonSet:
+		return &v.Spec.Template.ObjectMeta
+	case *appsv1.StatefulSet:
+		return &v.Spec.Template.ObjectMeta
+	case *appsv1beta2.Deployment:
+		return &v.Spec.Template.ObjectMeta
+	case *appsv1beta2.DaemonSet:
+		return &v.Spec.Template.ObjectMeta
+	case *appsv1beta2.StatefulSet:
+		return &v.Spec.Template.ObjectMeta
+	case *appsv1beta1.Deployment:
+		return &v.Spec.Template.ObjectMeta
+	case *appsv1beta1.StatefulSet:
+		return &v.Spec.Template.ObjectMeta
+	case *extensionsv1beta1.Deployment:
+		return &v.Spec.Template.ObjectMeta
+	case *extensionsv1beta1.DaemonSet:
+		return &v.Spec.Template.ObjectMeta
+	case *batchv1.Job:
+		return &v.Spec.Template.ObjectMeta
+	case *batchv1.CronJob:
+		return &v.Spec.JobTemplate.Spec.Template.ObjectMeta
+	case *batchv1beta1.CronJob:
+		return &v.Spec.JobTemplate.Spec.Template.ObjectMeta
+	case *unstructured.Unstructured:
+		fields, ok := podTemplateFields[v.GetKind()]
+		if !ok {
+			return nil
+		}
+
+		// Get the pod template without copying it, so the mutations are made on the object.
+		tpl := v.Object
+		for _, f := range fields {
+			m, ok := tpl[f].(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			tpl = m
+		}
+
+		return &unstructured.Unstructured{Object: tpl}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package mark
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ResourceMatcher matches the resources by group, version and kind. The `*` fields will
+// match any value, the empty version and kind will also match any value and the empty
+// group is the core group (like on Kubernetes API).
+type ResourceMatcher struct {
+	Group   string
+	Version string
+	Kind    string
+}
+
+func (r ResourceMatcher) matches(gvk schema.GroupVersionKind) bool {
+	return (r.Group == "*" || r.Group == gvk.Group) &&
+		(r.Version == "" || r.Version == "*" || r.Version == gvk.Version) &&
+		(r.Kind == "" || r.Kind == "*" || r.Kind == gvk.Kind)
+}
+
+// Rule are the marks that will be set on the resources that match the rule.
+type Rule struct {
+	// Resources are the resources that will match the rule, no resources will match all.
+	Resources []ResourceMatcher
+	// NamespaceSelector is a Kubernetes label selector that will match the namespace labels of
+	// the resources, the cluster scoped resources will not match a rule with namespace selector.
+	NamespaceSelector string
+	// ObjectSelector is a Kubernetes label selector that will match the resources labels.
+	ObjectSelector string
+	// Labels are the label marks of the rule, check `NewLabelMarker`.
+	Labels map[string]string
+	// Annotations are the annotation marks of the rule, check `NewAnnotationMarker`.
+	Annotations map[string]string
+	// Modes are the modes of the rule marks by key, the keys must be label or annotation marks of
+	// the rule. By default `MarkModeOverwrite` for the static marks and `MarkModeSetIfAbsent` for
+	// the templated marks, so these are rendered only once.
+	Modes map[string]MarkMode
+}
+
+// RuleMarkerConfig is the configuration of the rule marker.
+type RuleMarkerConfig struct {
+	// Rules are the marking rules, all the rules that match will be applied in order.
+	Rules []Rule
+	// PodTemplates will propagate the marks to the pod template of the workloads.
+	PodTemplates bool
+	// NamespaceLabelsGetter is used to get the namespace labels when using namespace selectors,
+	// and by the mark templates (check `MarkerConfig`).
+	NamespaceLabelsGetter NamespaceLabelsGetter
+}
+
+func (c *RuleMarkerConfig) defaults() error {
+	if c.NamespaceLabelsGetter == nil {
+		for _, r := range c.Rules {
+			if r.NamespaceSelector != "" {
+				return fmt.Errorf("namespace labels getter is required when using namespace selectors")
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewRuleMarker returns a new marker that will mark the resources with different marks
+// based on rules that match the resources by group version kind, namespace labels and
+// resource labels.
+func NewRuleMarker(config RuleMarkerConfig) (Marker, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	rules := make([]rule, 0, len(config.Rules))
+	for i, r := range config.Rules {
+		rl := rule{resources: r.Resources}
+
+		if r.NamespaceSelector != "" {
+			rl.nsSelector, err = labels.Parse(r.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d namespace selector is not valid: %w", i, err)
+			}
+		}
+
+		if r.ObjectSelector != "" {
+			rl.objSelector, err = labels.Parse(r.ObjectSelector)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d object selector is not valid: %w", i, err)
+			}
+		}
+
+		// A mode of a missing mark is a typo, otherwise the mode would be silently ignored.
+		for k := range r.Modes {
+			_, okLabel := r.Labels[k]
+			_, okAnnot := r.Annotations[k]
+			if !okLabel && !okAnnot {
+				return nil, fmt.Errorf("rule %d %q mode is not for a label or annotation mark", i, k)
+			}
+		}
+
+		markers := []Marker{}
+		if len(r.Labels) > 0 {
+			m, err := NewLabelMarker(MarkerConfig{
+				Marks:                 r.Labels,
+				Modes:                 r.Modes,
+				PodTemplates:          config.PodTemplates,
+				NamespaceLabelsGetter: config.NamespaceLabelsGetter,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("rule %d label marks are not valid: %w", i, err)
+			}
+			markers = append(markers, m)
+		}
+
+		if len(r.Annotations) > 0 {
+			m, err := NewAnnotationMarker(MarkerConfig{
+				Marks:                 r.Annotations,
+				Modes:                 r.Modes,
+				PodTemplates:          config.PodTemplates,
+				NamespaceLabelsGetter: config.NamespaceLabelsGetter,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("rule %d annotation marks are not valid: %w", i, err)
+			}
+			markers = append(markers, m)
+		}
+		rl.marker = NewChain(markers...)
+
+		rules = append(rules, rl)
+	}
+
+	return ruleMarker{
+		rules:    rules,
+		nsGetter: config.NamespaceLabelsGetter,
+	}, nil
+}
+
+type rule struct {
+	resources   []ResourceMatcher
+	nsSelector  labels.Selector
+	objSelector labels.Selector
+	marker      Marker
+}
+
+type ruleMarker struct {
+	rules    []rule
+	nsGetter NamespaceLabelsGetter
+}
+
+func (r ruleMarker) Mark(ctx context.Context, obj metav1.Object) (*Result, error) {
+	res := &Result{}
+
+	var gvk schema.GroupVersionKind
+	if robj, ok := obj.(runtime.Object); ok {
+		gvk = robj.GetObjectKind().GroupVersionKind()
+	}
+	objLabels := labels.Set(obj.GetLabels())
+
+	// Get the matching rules before marking, so the marks of a rule don't change the matching
+	// of the next rules.
+	var nsLabels labels.Set
+	nsLabelsLoaded, nsLabelsErr := false, false
+	matched := []rule{}
+	for _, rl := range r.rules {
+		if !rl.matchesResource(gvk) {
+			continue
+		}
+
+		if rl.objSelector != nil && !rl.objSelector.Matches(objLabels) {
+			continue
+		}
+
+		if rl.nsSelector != nil {
+			if obj.GetNamespace() == "" {
+				continue
+			}
+
+			if !nsLabelsLoaded {
+				l, err := r.nsGetter.GetNamespaceLabels(ctx, obj.GetNamespace())
+				if err != nil {
+					res.Warnings = append(res.Warnings, fmt.Sprintf("namespace %q labels could not be get for the mark rules: %s", obj.GetNamespace(), err))
+					nsLabelsErr = true
+				}
+				nsLabels = labels.Set(l)
+				nsLabelsLoaded = true
+			}
+
+			// Without namespace labels, the rules with namespace selector can't match.
+			if nsLabelsErr || !rl.nsSelector.Matches(nsLabels) {
+				continue
+			}
+		}
+
+		matched = append(matched, rl)
+	}
+
+	for _, rl := range matched {
+		mres, err := rl.marker.Mark(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		res.Warnings = append(res.Warnings, mres.Warnings...)
+	}
+
+	return res, nil
+}
+
+func (r rule) matchesResource(gvk schema.GroupVersionKind) bool {
+	if len(r.resources) == 0 {
+		return true
+	}
+
+	for _, rm := range r.resources {
+		if rm.matches(gvk) {
+			return true
+		}
+	}
+
+	return false
+}
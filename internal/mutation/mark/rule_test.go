@@ -0,0 +1,177 @@
+package mark_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
+)
+
+func newRuleDeployment(ns string, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns, Labels: labels},
+	}
+}
+
+func newRulePod(ns string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns, Labels: labels},
+	}
+}
+
+func TestRuleMarkerMark(t *testing.T) {
+	nsGetter := testNamespaceLabelsGetter{
+		"default": {},
+		"team-a":  {"tenant": "team-a"},
+		"team-b":  {"tenant": "team-b"},
+	}
+
+	rules := []mark.Rule{
+		{
+			Resources: []mark.ResourceMatcher{{Group: "apps", Kind: "Deployment"}},
+			Labels:    map[string]string{"workload": "true"},
+		},
+		{
+			Resources: []mark.ResourceMatcher{{Group: "", Version: "v1", Kind: "Pod"}},
+			Labels:    map[string]string{"pod": "true"},
+		},
+		{
+			NamespaceSelector: "tenant=team-a",
+			Labels:            map[string]string{"tenant": "team-a"},
+			Annotations:       map[string]string{"owner": "team-a@example.com"},
+		},
+		{
+			Resources:      []mark.ResourceMatcher{{Group: "*"}},
+			ObjectSelector: "tier=frontend",
+			Labels:         map[string]string{"exposed": "true", "tier": "web"},
+			Modes:          map[string]mark.MarkMode{"tier": mark.MarkModeSetIfAbsent},
+		},
+	}
+
+	tests := map[string]struct {
+		rules       []mark.Rule
+		obj         metav1.Object
+		expObj      metav1.Object
+		expWarnings []string
+	}{
+		"Having a deployment, the group kind rule should match.": {
+			rules:  rules,
+			obj:    newRuleDeployment("default", nil),
+			expObj: newRuleDeployment("default", map[string]string{"workload": "true"}),
+		},
+
+		"Having a pod, the core group rule should match.": {
+			rules:  rules,
+			obj:    newRulePod("default", nil),
+			expObj: newRulePod("default", map[string]string{"pod": "true"}),
+		},
+
+		"Having a pod on a namespace matching the selector, the group and namespace rules should match.": {
+			rules: rules,
+			obj:   newRulePod("team-a", nil),
+			expObj: func() metav1.Object {
+				p := newRulePod("team-a", map[string]string{"pod": "true", "tenant": "team-a"})
+				p.Annotations = map[string]string{"owner": "team-a@example.com"}
+				return p
+			}(),
+		},
+
+		"Having a deployment on a namespace not matching the selector, the namespace rule should not match.": {
+			rules:  rules,
+			obj:    newRuleDeployment("team-b", nil),
+			expObj: newRuleDeployment("team-b", map[string]string{"workload": "true"}),
+		},
+
+		"Having a deployment matching the object selector, the object selector rule should match with its modes.": {
+			rules: rules,
+			obj:   newRuleDeployment("default", map[string]string{"tier": "frontend"}),
+			expObj: newRuleDeployment("default", map[string]string{
+				"tier":     "frontend",
+				"workload": "true",
+				"exposed":  "true",
+			}),
+		},
+
+		"Having a deployment on a missing namespace, the namespace rule should not match and warn.": {
+			rules:       rules,
+			obj:         newRuleDeployment("missing", nil),
+			expObj:      newRuleDeployment("missing", map[string]string{"workload": "true"}),
+			expWarnings: []string{`namespace "missing" labels could not be get for the mark rules: namespaces "missing" not found`},
+		},
+
+		"Having a rule that matches all the resources, it should match.": {
+			rules: []mark.Rule{
+				{Labels: map[string]string{"all": "true"}},
+			},
+			obj:    newRulePod("", nil),
+			expObj: newRulePod("", map[string]string{"all": "true"}),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m, err := mark.NewRuleMarker(mark.RuleMarkerConfig{
+				Rules:                 test.rules,
+				NamespaceLabelsGetter: nsGetter,
+			})
+			require.NoError(err)
+
+			res, err := m.Mark(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+			assert.Equal(test.expWarnings, res.Warnings)
+		})
+	}
+}
+
+func TestNewRuleMarkerInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		config mark.RuleMarkerConfig
+	}{
+		"A namespace selector without namespace labels getter should be invalid.": {
+			config: mark.RuleMarkerConfig{
+				Rules: []mark.Rule{{NamespaceSelector: "tenant=team-a"}},
+			},
+		},
+
+		"An invalid object selector should be invalid.": {
+			config: mark.RuleMarkerConfig{
+				Rules: []mark.Rule{{ObjectSelector: "tier in ("}},
+			},
+		},
+
+		"An invalid mark template should be invalid.": {
+			config: mark.RuleMarkerConfig{
+				Rules: []mark.Rule{{Labels: map[string]string{"test": "{{ .Name "}}},
+			},
+		},
+
+		"A mode for a missing mark should be invalid.": {
+			config: mark.RuleMarkerConfig{
+				Rules: []mark.Rule{{
+					Labels: map[string]string{"team": "team-a"},
+					Modes:  map[string]mark.MarkMode{"taem": mark.MarkModeRejectIfDifferent},
+				}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := mark.NewRuleMarker(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package mark
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StripConfig is the configuration of the strip marker.
+type StripConfig struct {
+	// Labels are the label keys that will be removed, the keys ending with `*` will be used
+	// as prefixes (e.g: `platform.example.com/*`).
+	Labels []string
+	// Annotations are the annotation keys that will be removed, the keys ending with `*` will
+	// be used as prefixes.
+	Annotations []string
+	// PodTemplates will remove the marks also from the pod template of the workloads.
+	PodTemplates bool
+}
+
+// NewStripMarker returns a new marker that removes the labels and annotations that match
+// the configured keys or prefixes, e.g: to remove the marks that only the platform can set.
+// Every removal will be returned as a result warning.
+func NewStripMarker(config StripConfig) Marker {
+	return stripmarker{
+		labels:       newKeyMatcher(config.Labels),
+		annotations:  newKeyMatcher(config.Annotations),
+		podTemplates: config.PodTemplates,
+	}
+}
+
+type stripmarker struct {
+	labels       keyMatcher
+	annotations  keyMatcher
+	podTemplates bool
+}
+
+func (s stripmarker) Mark(_ context.Context, obj metav1.Object) (*Result, error) {
+	warnings := s.strip(obj, "")
+
+	if s.podTemplates {
+		tplMeta := getPodTemplateMeta(obj)
+		if tplMeta != nil {
+			warnings = append(warnings, s.strip(tplMeta, "pod template ")...)
+		}
+	}
+
+	return &Result{Warnings: warnings}, nil
+}
+
+func (s stripmarker) strip(obj metav1.Object, prefix string) []string {
+	warnings := []string{}
+
+	labels, removed := s.labels.remove(obj.GetLabels())
+	if len(removed) > 0 {
+		obj.SetLabels(labels)
+		for _, k := range removed {
+			warnings = append(warnings, fmt.Sprintf("%slabel %q has been removed", prefix, k))
+		}
+	}
+
+	annotations, removed := s.annotations.remove(obj.GetAnnotations())
+	if len(removed) > 0 {
+		obj.SetAnnotations(annotations)
+		for _, k := range removed {
+			warnings = append(warnings, fmt.Sprintf("%sannotation %q has been removed", prefix, k))
+		}
+	}
+
+	return warnings
+}
+
+// keyMatcher matches keys by exact key or prefix.
+type keyMatcher struct {
+	keys     map[string]struct{}
+	prefixes []string
+}
+
+func newKeyMatcher(keys []string) keyMatcher {
+	m := keyMatcher{keys: map[string]struct{}{}}
+	for _, k := range keys {
+		if strings.HasSuffix(k, "*") {
+			m.prefixes = append(m.prefixes, strings.TrimSuffix(k, "*"))
+			continue
+		}
+		m.keys[k] = struct{}{}
+	}
+
+	return m
+}
+
+func (m keyMatcher) matches(key string) bool {
+	if _, ok := m.keys[key]; ok {
+		return true
+	}
+
+	for _, p := range m.prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remove returns the marks without the matched keys and the sorted removed keys.
+func (m keyMatcher) remove(marks map[string]string) (map[string]string, []string) {
+	removed := []string{}
+	for k := range marks {
+		if m.matches(k) {
+			removed = append(removed, k)
+		}
+	}
+
+	if len(removed) == 0 {
+		return marks, nil
+	}
+
+	sort.Strings(removed)
+	for _, k := range removed {
+		delete(marks, k)
+	}
+
+	return marks, removed
+}
@@ -0,0 +1,132 @@
+package mark_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/mutation/mark"
+)
+
+func TestStripMarkerMark(t *testing.T) {
+	tests := map[string]struct {
+		config      mark.StripConfig
+		obj         metav1.Object
+		expObj      metav1.Object
+		expWarnings []string
+	}{
+		"Having an object without matching marks, it should not be mutated.": {
+			config: mark.StripConfig{
+				Labels:      []string{"platform.example.com/*", "owner"},
+				Annotations: []string{"platform.example.com/*"},
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "test"},
+					Annotations: map[string]string{"test": "value"},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "test"},
+					Annotations: map[string]string{"test": "value"},
+				},
+			},
+			expWarnings: []string{},
+		},
+
+		"Having an object with matching marks, the marks should be removed and warned.": {
+			config: mark.StripConfig{
+				Labels:      []string{"platform.example.com/*", "owner"},
+				Annotations: []string{"platform.example.com/tier"},
+			},
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app":                       "test",
+						"owner":                     "team-a",
+						"owner-team":                "team-a",
+						"platform.example.com/team": "team-a",
+						"platform.example.com/cost": "low",
+					},
+					Annotations: map[string]string{
+						"platform.example.com/tier": "gold",
+						"platform.example.com/cost": "low",
+					},
+				},
+			},
+			expObj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app":        "test",
+						"owner-team": "team-a",
+					},
+					Annotations: map[string]string{
+						"platform.example.com/cost": "low",
+					},
+				},
+			},
+			expWarnings: []string{
+				`label "owner" has been removed`,
+				`label "platform.example.com/cost" has been removed`,
+				`label "platform.example.com/team" has been removed`,
+				`annotation "platform.example.com/tier" has been removed`,
+			},
+		},
+
+		"Having a deployment with pod templates, the pod template marks should be removed and warned.": {
+			config: mark.StripConfig{
+				Labels:       []string{"platform.example.com/*"},
+				PodTemplates: true,
+			},
+			obj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"platform.example.com/team": "team-a"},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"app": "test", "platform.example.com/team": "team-a"},
+						},
+					},
+				},
+			},
+			expObj: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"app": "test"},
+						},
+					},
+				},
+			},
+			expWarnings: []string{
+				`label "platform.example.com/team" has been removed`,
+				`pod template label "platform.example.com/team" has been removed`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			m := mark.NewStripMarker(test.config)
+
+			res, err := m.Mark(context.TODO(), test.obj)
+			require.NoError(err)
+
+			assert.Equal(test.expObj, test.obj)
+			assert.Equal(test.expWarnings, res.Warnings)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package mark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type usernameCtxKey struct{}
+
+// ContextWithUsername returns a new context with the username that requested the
+// marking, this username can be used on the mark templates.
+func ContextWithUsername(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, usernameCtxKey{}, username)
+}
+
+func usernameFromContext(ctx context.Context) string {
+	username, _ := ctx.Value(usernameCtxKey{}).(string)
+	return username
+}
+
+// templateData is the data the mark templates can use.
+type templateData struct {
+	// Namespace is the namespace of the object.
+	Namespace string
+	// Kind is the kind of the object.
+	Kind string
+	// Name is the name of the object, the objects created with `generateName` don't have it
+	// yet, so in that case it will be the generate name prefix (e.g: `my-app-`).
+	Name string
+	// Username is the user that requested the marking.
+	Username string
+	// CreationTimestamp is the creation time of the object, the objects being created don't have
+	// it yet, so in that case it will be the marking time, truncated to seconds like the Kubernetes
+	// timestamps.
+	CreationTimestamp time.Time
+	// Labels are the labels of the object before being marked.
+	Labels map[string]string
+	// NamespaceLabels are the labels of the object namespace, empty for the cluster scoped objects,
+	// the missing namespaces and the markers without namespace labels getter.
+	NamespaceLabels map[string]string
+}
+
+func newTemplateData(ctx context.Context, obj metav1.Object, nsGetter NamespaceLabelsGetter) (templateData, error) {
+	kind := ""
+	if robj, ok := obj.(runtime.Object); ok {
+		kind = robj.GetObjectKind().GroupVersionKind().Kind
+	}
+
+	creation := obj.GetCreationTimestamp().Time
+	if creation.IsZero() {
+		creation = time.Now().UTC().Truncate(time.Second)
+	}
+
+	name := obj.GetName()
+	if name == "" {
+		name = obj.GetGenerateName()
+	}
+
+	labels := map[string]string{}
+	for k, v := range obj.GetLabels() {
+		labels[k] = v
+	}
+
+	nsLabels := map[string]string{}
+	if ns := obj.GetNamespace(); ns != "" && nsGetter != nil {
+		l, err := nsGetter.GetNamespaceLabels(ctx, ns)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return templateData{}, fmt.Errorf("could not get namespace %q labels: %w", ns, err)
+		}
+		for k, v := range l {
+			nsLabels[k] = v
+		}
+	}
+
+	return templateData{
+		Namespace:         obj.GetNamespace(),
+		Kind:              kind,
+		Name:              name,
+		Username:          usernameFromContext(ctx),
+		CreationTimestamp: creation,
+		Labels:            labels,
+		NamespaceLabels:   nsLabels,
+	}, nil
+}
+
+// markValue is the value of a mark, it can be a static value or a Go template
+// (e.g: `{{ .Namespace }}`) that is rendered per object.
+// By default the static marks overwrite the existing values and the templated marks are only set
+// when absent, otherwise the templates would be rendered again on every update (e.g: a
+// `created-by={{ .Username }}` mark would be the last user that updated the object, and changing
+// the pod template marks would trigger a rollout of the workload).
+type markValue struct {
+	value string
+	tpl   *template.Template
+	mode  MarkMode
+}
+
+func newMarkValue(key, value string, mode MarkMode) (markValue, error) {
+	if mode != "" && !mode.valid() {
+		return markValue{}, fmt.Errorf("invalid %q mark mode: %q", key, mode)
+	}
+
+	if !strings.Contains(value, "{{") {
+		if mode == "" {
+			mode = MarkModeOverwrite
+		}
+		return markValue{value: value, mode: mode}, nil
+	}
+
+	if mode == "" {
+		mode = MarkModeSetIfAbsent
+	}
+
+	tpl, err := template.New(key).Option("missingkey=zero").Parse(value)
+	if err != nil {
+		return markValue{}, fmt.Errorf("invalid %q mark template: %w", key, err)
+	}
+
+	return markValue{tpl: tpl, mode: mode}, nil
+}
+
+func (m markValue) render(data templateData) (string, error) {
+	if m.tpl == nil {
+		return m.value, nil
+	}
+
+	var b bytes.Buffer
+	err := m.tpl.Execute(&b, data)
+	if err != nil {
+		return "", fmt.Errorf("could not render %q mark template: %w", m.tpl.Name(), err)
+	}
+
+	return b.String(), nil
+}
+
+// renderLabel renders the mark as a label value, the rendered templates will be sanitized
+// to be valid label values.
+func (m markValue) renderLabel(data templateData) (string, error) {
+	v, err := m.render(data)
+	if err != nil {
+		return "", err
+	}
+
+	if m.tpl != nil {
+		v = sanitizeLabelValue(v)
+	}
+
+	return v, nil
+}
+
+// templated returns true if any of the marks is a template.
+func templated(marks map[string]markValue) bool {
+	for _, mv := range marks {
+		if mv.tpl != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newMarkValues(marks map[string]string, modes map[string]MarkMode) (map[string]markValue, error) {
+	ms := make(map[string]markValue, len(marks))
+	for k, v := range marks {
+		mv, err := newMarkValue(k, v, modes[k])
+		if err != nil {
+			return nil, err
+		}
+		ms[k] = mv
+	}
+
+	return ms, nil
+}
+
+const maxLabelValueLength = 63
+
+var invalidLabelValueChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// sanitizeLabelValue converts a value into a valid Kubernetes label value, the invalid
+// characters are replaced with `-` (e.g: `system:serviceaccount:ns:sa` will be
+// `system-serviceaccount-ns-sa`), it's truncated to the max length and the
+// non alphanumeric characters of the beginning and end are removed.
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelValueChars.ReplaceAllString(value, "-")
+	if len(value) > maxLabelValueLength {
+		value = value[:maxLabelValueLength]
+	}
+
+	return strings.TrimFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
@@ -0,0 +1,55 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+)
+
+// PodMonitorSafer will ensure the pod monitor has safe settings, and mutate them instead.
+type PodMonitorSafer interface {
+	EnsureSafety(ctx context.Context, pm *monitoringv1.PodMonitor) (*Result, error)
+}
+
+type podMonitorSafer struct {
+	safer
+}
+
+// NewPodMonitorSafer returns a new PodMonitorSafer that will mutate the received pod
+// monitor in case it don't have safe settings, it has the same checks and modes as
+// `NewServiceMonitorSafer`.
+func NewPodMonitorSafer(config SaferConfig) (PodMonitorSafer, error) {
+	s, err := newSafer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return podMonitorSafer{safer: s}, nil
+}
+
+func (s podMonitorSafer) EnsureSafety(_ context.Context, pm *monitoringv1.PodMonitor) (*Result, error) {
+	res := &Result{}
+
+	endpoints := make([]monitoringv1.PodMetricsEndpoint, 0, len(pm.Spec.PodMetricsEndpoints))
+	for i, e := range pm.Spec.PodMetricsEndpoints {
+		e.Interval, e.ScrapeTimeout = s.safeScrapeSettings(res, fmt.Sprintf("endpoint %d ", i), e.Interval, e.ScrapeTimeout)
+		endpoints = append(endpoints, e)
+	}
+	pm.Spec.PodMetricsEndpoints = endpoints
+
+	s.safeLimits(res, &pm.Spec.SampleLimit, &pm.Spec.TargetLimit, &pm.Spec.LabelLimit, &pm.Spec.LabelNameLengthLimit, &pm.Spec.LabelValueLengthLimit)
+
+	return res, nil
+}
+
+// DummyPodMonitorSafer is a PodMonitorSafer that doesn't do anything.
+const DummyPodMonitorSafer = dummyPodMonitorSafer(0)
+
+type dummyPodMonitorSafer int
+
+var _ PodMonitorSafer = DummyPodMonitorSafer
+
+func (dummyPodMonitorSafer) EnsureSafety(ctx context.Context, pm *monitoringv1.PodMonitor) (*Result, error) {
+	return &Result{}, nil
+}
@@ -0,0 +1,116 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/k8s-webhook-example/internal/mutation/prometheus"
+)
+
+func TestPodMonitorSafer(t *testing.T) {
+	tests := map[string]struct {
+		config        prometheus.SaferConfig
+		podMon        *monitoringv1.PodMonitor
+		expPodMon     *monitoringv1.PodMonitor
+		expWarnings   []string
+		expViolations []string
+	}{
+		"Having a safe pod monitor, it should not mutate the pod monitor.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval: 15 * time.Second,
+				MaxScrapeTimeout:  10 * time.Second,
+				SampleLimit:       prometheus.Limit{Max: 1000},
+			},
+			podMon: &monitoringv1.PodMonitor{
+				Spec: monitoringv1.PodMonitorSpec{
+					PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{{Interval: "30s", ScrapeTimeout: "10s"}},
+					SampleLimit:         500,
+				},
+			},
+			expPodMon: &monitoringv1.PodMonitor{
+				Spec: monitoringv1.PodMonitorSpec{
+					PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{{Interval: "30s", ScrapeTimeout: "10s"}},
+					SampleLimit:         500,
+				},
+			},
+		},
+
+		"Having an unsafe pod monitor, it should mutate the pod monitor.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval:    15 * time.Second,
+				DefaultScrapeTimeout: 10 * time.Second,
+				MaxScrapeTimeout:     20 * time.Second,
+				SampleLimit:          prometheus.Limit{Default: 1000, Max: 5000},
+				LabelLimit:           prometheus.Limit{Max: 50},
+			},
+			podMon: &monitoringv1.PodMonitor{
+				Spec: monitoringv1.PodMonitorSpec{
+					PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{
+						{Interval: "5s"},
+						{Interval: "1m", ScrapeTimeout: "30s"},
+					},
+					LabelLimit: 100,
+				},
+			},
+			expPodMon: &monitoringv1.PodMonitor{
+				Spec: monitoringv1.PodMonitorSpec{
+					PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{
+						{Interval: "15s", ScrapeTimeout: "10s"},
+						{Interval: "1m", ScrapeTimeout: "20s"},
+					},
+					SampleLimit: 1000,
+					LabelLimit:  50,
+				},
+			},
+			expWarnings: []string{
+				`endpoint 0 interval "5s" has been changed to "15s"`,
+				`endpoint 0 scrapeTimeout has been set to "10s"`,
+				`endpoint 1 scrapeTimeout "30s" has been changed to "20s"`,
+				"sampleLimit has been set to 1000",
+				"labelLimit 100 has been changed to 50",
+			},
+		},
+
+		"Having an unsafe pod monitor with rules in validate mode, it should report violations.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval: 15 * time.Second,
+				Modes: map[prometheus.SafetyRule]prometheus.SafetyMode{
+					prometheus.SafetyRuleScrapeInterval: prometheus.SafetyModeValidate,
+				},
+			},
+			podMon: &monitoringv1.PodMonitor{
+				Spec: monitoringv1.PodMonitorSpec{
+					PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{{Interval: "5s", ScrapeTimeout: "5s"}},
+				},
+			},
+			expPodMon: &monitoringv1.PodMonitor{
+				Spec: monitoringv1.PodMonitorSpec{
+					PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{{Interval: "5s", ScrapeTimeout: "5s"}},
+				},
+			},
+			expViolations: []string{`endpoint 0 interval "5s" is not safe, expected "15s"`},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s, err := prometheus.NewPodMonitorSafer(test.config)
+			require.NoError(err)
+
+			res, err := s.EnsureSafety(context.TODO(), test.podMon)
+			require.NoError(err)
+
+			assert.Equal(test.expPodMon, test.podMon)
+			assert.Equal(test.expWarnings, res.Warnings)
+			assert.Equal(test.expViolations, res.Violations)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package prometheus
+
+import (
+	"context"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+)
+
+// ProbeSafer will ensure the probe has safe settings, and mutate them instead.
+type ProbeSafer interface {
+	EnsureSafety(ctx context.Context, p *monitoringv1.Probe) (*Result, error)
+}
+
+type probeSafer struct {
+	safer
+}
+
+// NewProbeSafer returns a new ProbeSafer that will mutate the received probe in case
+// it don't have safe settings, it has the same checks and modes as `NewServiceMonitorSafer`.
+func NewProbeSafer(config SaferConfig) (ProbeSafer, error) {
+	s, err := newSafer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return probeSafer{safer: s}, nil
+}
+
+func (s probeSafer) EnsureSafety(_ context.Context, p *monitoringv1.Probe) (*Result, error) {
+	res := &Result{}
+
+	// Probes don't have endpoints, the scrape settings are on the spec.
+	p.Spec.Interval, p.Spec.ScrapeTimeout = s.safeScrapeSettings(res, "", p.Spec.Interval, p.Spec.ScrapeTimeout)
+	s.safeLimits(res, &p.Spec.SampleLimit, &p.Spec.TargetLimit, &p.Spec.LabelLimit, &p.Spec.LabelNameLengthLimit, &p.Spec.LabelValueLengthLimit)
+
+	return res, nil
+}
+
+// DummyProbeSafer is a ProbeSafer that doesn't do anything.
+const DummyProbeSafer = dummyProbeSafer(0)
+
+type dummyProbeSafer int
+
+var _ ProbeSafer = DummyProbeSafer
+
+func (dummyProbeSafer) EnsureSafety(ctx context.Context, p *monitoringv1.Probe) (*Result, error) {
+	return &Result{}, nil
+}
@@ -0,0 +1,83 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/k8s-webhook-example/internal/mutation/prometheus"
+)
+
+func TestProbeSafer(t *testing.T) {
+	tests := map[string]struct {
+		config        prometheus.SaferConfig
+		probe         *monitoringv1.Probe
+		expProbe      *monitoringv1.Probe
+		expWarnings   []string
+		expViolations []string
+	}{
+		"Having a safe probe, it should not mutate the probe.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval: 15 * time.Second,
+				TargetLimit:       prometheus.Limit{Max: 100},
+			},
+			probe:    &monitoringv1.Probe{Spec: monitoringv1.ProbeSpec{Interval: "30s", TargetLimit: 10}},
+			expProbe: &monitoringv1.Probe{Spec: monitoringv1.ProbeSpec{Interval: "30s", TargetLimit: 10}},
+		},
+
+		"Having an unsafe probe, it should mutate the probe.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval:     15 * time.Second,
+				MinScrapeTimeout:      5 * time.Second,
+				TargetLimit:           prometheus.Limit{Max: 100},
+				LabelValueLengthLimit: prometheus.Limit{Default: 200},
+			},
+			probe: &monitoringv1.Probe{Spec: monitoringv1.ProbeSpec{ScrapeTimeout: "1s", TargetLimit: 500}},
+			expProbe: &monitoringv1.Probe{Spec: monitoringv1.ProbeSpec{
+				Interval:              "15s",
+				ScrapeTimeout:         "5s",
+				TargetLimit:           100,
+				LabelValueLengthLimit: 200,
+			}},
+			expWarnings: []string{
+				`interval has been set to "15s"`,
+				`scrapeTimeout "1s" has been changed to "5s"`,
+				"targetLimit 500 has been changed to 100",
+				"labelValueLengthLimit has been set to 200",
+			},
+		},
+
+		"Having an unsafe probe with rules in validate mode, it should report violations.": {
+			config: prometheus.SaferConfig{
+				TargetLimit: prometheus.Limit{Max: 100},
+				Modes: map[prometheus.SafetyRule]prometheus.SafetyMode{
+					prometheus.SafetyRuleTargetLimit: prometheus.SafetyModeValidate,
+				},
+			},
+			probe:         &monitoringv1.Probe{Spec: monitoringv1.ProbeSpec{TargetLimit: 500}},
+			expProbe:      &monitoringv1.Probe{Spec: monitoringv1.ProbeSpec{TargetLimit: 500}},
+			expViolations: []string{"targetLimit 500 is not safe, expected 100"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s, err := prometheus.NewProbeSafer(test.config)
+			require.NoError(err)
+
+			res, err := s.EnsureSafety(context.TODO(), test.probe)
+			require.NoError(err)
+
+			assert.Equal(test.expProbe, test.probe)
+			assert.Equal(test.expWarnings, res.Warnings)
+			assert.Equal(test.expViolations, res.Violations)
+		})
+	}
+}
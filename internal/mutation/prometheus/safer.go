@@ -0,0 +1,272 @@
+package prometheus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// Result is the result of ensuring the safety of a resource.
+type Result struct {
+	// Warnings are the changes made to the resource, so they can be reported to the user.
+	Warnings []string
+	// Violations are the unsafe settings of the rules in validate mode, these are not mutated.
+	Violations []string
+}
+
+// SafetyRule is a safety rule that the safers enforce.
+type SafetyRule string
+
+const (
+	// SafetyRuleScrapeInterval is the minimum scrape interval rule.
+	SafetyRuleScrapeInterval SafetyRule = "scrape-interval"
+	// SafetyRuleScrapeTimeout is the default, minimum, maximum and interval bound scrape timeout rule.
+	SafetyRuleScrapeTimeout SafetyRule = "scrape-timeout"
+	// SafetyRuleSampleLimit is the sample limit rule.
+	SafetyRuleSampleLimit SafetyRule = "sample-limit"
+	// SafetyRuleTargetLimit is the target limit rule.
+	SafetyRuleTargetLimit SafetyRule = "target-limit"
+	// SafetyRuleLabelLimit is the label limit rule.
+	SafetyRuleLabelLimit SafetyRule = "label-limit"
+	// SafetyRuleLabelNameLengthLimit is the label name length limit rule.
+	SafetyRuleLabelNameLengthLimit SafetyRule = "label-name-length-limit"
+	// SafetyRuleLabelValueLengthLimit is the label value length limit rule.
+	SafetyRuleLabelValueLengthLimit SafetyRule = "label-value-length-limit"
+)
+
+var validSafetyRules = map[SafetyRule]bool{
+	SafetyRuleScrapeInterval:        true,
+	SafetyRuleScrapeTimeout:         true,
+	SafetyRuleSampleLimit:           true,
+	SafetyRuleTargetLimit:           true,
+	SafetyRuleLabelLimit:            true,
+	SafetyRuleLabelNameLengthLimit:  true,
+	SafetyRuleLabelValueLengthLimit: true,
+}
+
+// SafetyMode is how a safety rule is enforced.
+type SafetyMode string
+
+const (
+	// SafetyModeMutate will mutate the unsafe settings with safe ones.
+	SafetyModeMutate SafetyMode = "mutate"
+	// SafetyModeValidate will not mutate the unsafe settings, these will be reported as violations
+	// so they can be denied.
+	SafetyModeValidate SafetyMode = "validate"
+)
+
+func (m SafetyMode) valid() bool {
+	return m == SafetyModeMutate || m == SafetyModeValidate
+}
+
+// Limit is the configuration of a scrape limit, the zero values disable the setting.
+type Limit struct {
+	// Default is the limit used when the limit is unset, if missing `Max` will be used.
+	Default uint64
+	// Max is the maximum limit.
+	Max uint64
+}
+
+func (l Limit) validate() error {
+	if l.Max != 0 && l.Default > l.Max {
+		return fmt.Errorf("default limit can't be greater than the maximum limit")
+	}
+
+	return nil
+}
+
+// SaferConfig is the configuration of the Prometheus operator monitoring resources safers,
+// the zero values disable the setting.
+type SaferConfig struct {
+	// MinScrapeInterval is the minimum scrape interval, also used when the interval is missing.
+	MinScrapeInterval time.Duration
+	// MinScrapeTimeout is the minimum scrape timeout.
+	MinScrapeTimeout time.Duration
+	// MaxScrapeTimeout is the maximum scrape timeout.
+	MaxScrapeTimeout time.Duration
+	// DefaultScrapeTimeout is the scrape timeout used when the timeout is missing.
+	DefaultScrapeTimeout time.Duration
+	// SampleLimit is the limit of samples per scrape.
+	SampleLimit Limit
+	// TargetLimit is the limit of scraped targets.
+	TargetLimit Limit
+	// LabelLimit is the limit of labels per sample.
+	LabelLimit Limit
+	// LabelNameLengthLimit is the limit of the label names length.
+	LabelNameLengthLimit Limit
+	// LabelValueLengthLimit is the limit of the label values length.
+	LabelValueLengthLimit Limit
+	// Modes are the modes of the safety rules, by default `SafetyModeMutate`.
+	Modes map[SafetyRule]SafetyMode
+}
+
+func (c *SaferConfig) defaults() error {
+	if c.MinScrapeTimeout != 0 && c.MaxScrapeTimeout != 0 && c.MinScrapeTimeout > c.MaxScrapeTimeout {
+		return fmt.Errorf("minimum scrape timeout can't be greater than the maximum scrape timeout")
+	}
+
+	if c.DefaultScrapeTimeout != 0 {
+		if c.DefaultScrapeTimeout < c.MinScrapeTimeout {
+			return fmt.Errorf("default scrape timeout can't be less than the minimum scrape timeout")
+		}
+
+		if c.MaxScrapeTimeout != 0 && c.DefaultScrapeTimeout > c.MaxScrapeTimeout {
+			return fmt.Errorf("default scrape timeout can't be greater than the maximum scrape timeout")
+		}
+	}
+
+	limits := map[string]Limit{
+		"sample":             c.SampleLimit,
+		"target":             c.TargetLimit,
+		"label":              c.LabelLimit,
+		"label name length":  c.LabelNameLengthLimit,
+		"label value length": c.LabelValueLengthLimit,
+	}
+	for name, l := range limits {
+		err := l.validate()
+		if err != nil {
+			return fmt.Errorf("%s limit is not valid: %w", name, err)
+		}
+	}
+
+	for rule, mode := range c.Modes {
+		if !validSafetyRules[rule] {
+			return fmt.Errorf("unknown safety rule %q", rule)
+		}
+
+		if !mode.valid() {
+			return fmt.Errorf("safety rule %q has an invalid %q mode", rule, mode)
+		}
+	}
+
+	return nil
+}
+
+// safer has the safety rules shared by all the Prometheus operator monitoring resources safers.
+type safer struct {
+	cfg SaferConfig
+}
+
+func newSafer(config SaferConfig) (safer, error) {
+	err := config.defaults()
+	if err != nil {
+		return safer{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return safer{cfg: config}, nil
+}
+
+// prometheusDefaultScrapeTimeout is the scrape timeout that Prometheus uses when missing.
+const prometheusDefaultScrapeTimeout = model.Duration(10 * time.Second)
+
+// safeScrapeSettings returns the interval and timeout of a scrape endpoint after enforcing
+// their safety rules. The durations are parsed and formatted in the Prometheus format (e.g: `1d`,
+// `1m30s`), the missing timeouts without a default timeout are checked as the Prometheus default one.
+func (s safer) safeScrapeSettings(res *Result, prefix, interval, timeout string) (string, string) {
+	// Set safe/correct scrape intervals if required.
+	if s.cfg.MinScrapeInterval != 0 {
+		minInterval := model.Duration(s.cfg.MinScrapeInterval)
+		i, err := model.ParseDuration(interval)
+		if err != nil || i < minInterval {
+			interval = s.enforceDuration(res, SafetyRuleScrapeInterval, prefix+"interval", interval, minInterval.String())
+		}
+	}
+
+	// Set safe/correct scrape timeouts if required.
+	changed := false
+	safeTimeout, err := model.ParseDuration(timeout)
+	if err != nil {
+		safeTimeout = prometheusDefaultScrapeTimeout
+		if s.cfg.DefaultScrapeTimeout != 0 {
+			safeTimeout = model.Duration(s.cfg.DefaultScrapeTimeout)
+			changed = true
+		}
+	}
+
+	switch {
+	case safeTimeout < model.Duration(s.cfg.MinScrapeTimeout):
+		safeTimeout = model.Duration(s.cfg.MinScrapeTimeout)
+		changed = true
+	case s.cfg.MaxScrapeTimeout != 0 && safeTimeout > model.Duration(s.cfg.MaxScrapeTimeout):
+		safeTimeout = model.Duration(s.cfg.MaxScrapeTimeout)
+		changed = true
+	}
+
+	// The timeout can't be greater than the interval (Prometheus rejects these).
+	i, err := model.ParseDuration(interval)
+	if err == nil && safeTimeout > i {
+		safeTimeout = i
+		changed = true
+	}
+
+	if changed {
+		timeout = s.enforceDuration(res, SafetyRuleScrapeTimeout, prefix+"scrapeTimeout", timeout, safeTimeout.String())
+	}
+
+	return interval, timeout
+}
+
+// enforceDuration enforces the safe value of a duration field based on the rule mode.
+func (s safer) enforceDuration(res *Result, rule SafetyRule, field, value, safeValue string) string {
+	if s.cfg.Modes[rule] == SafetyModeValidate {
+		if value == "" {
+			res.Violations = append(res.Violations, fmt.Sprintf("%s is missing, expected %q", field, safeValue))
+		} else {
+			res.Violations = append(res.Violations, fmt.Sprintf("%s %q is not safe, expected %q", field, value, safeValue))
+		}
+		return value
+	}
+
+	if value == "" {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s has been set to %q", field, safeValue))
+	} else {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s %q has been changed to %q", field, value, safeValue))
+	}
+	return safeValue
+}
+
+// safeLimits enforces the safety rules of the scrape limits.
+func (s safer) safeLimits(res *Result, sampleLimit, targetLimit, labelLimit, labelNameLengthLimit, labelValueLengthLimit *uint64) {
+	*sampleLimit = s.safeLimit(res, SafetyRuleSampleLimit, "sampleLimit", *sampleLimit, s.cfg.SampleLimit)
+	*targetLimit = s.safeLimit(res, SafetyRuleTargetLimit, "targetLimit", *targetLimit, s.cfg.TargetLimit)
+	*labelLimit = s.safeLimit(res, SafetyRuleLabelLimit, "labelLimit", *labelLimit, s.cfg.LabelLimit)
+	*labelNameLengthLimit = s.safeLimit(res, SafetyRuleLabelNameLengthLimit, "labelNameLengthLimit", *labelNameLengthLimit, s.cfg.LabelNameLengthLimit)
+	*labelValueLengthLimit = s.safeLimit(res, SafetyRuleLabelValueLengthLimit, "labelValueLengthLimit", *labelValueLengthLimit, s.cfg.LabelValueLengthLimit)
+}
+
+// safeLimit returns the value of a limit after enforcing its safety rule.
+func (s safer) safeLimit(res *Result, rule SafetyRule, field string, value uint64, l Limit) uint64 {
+	def := l.Default
+	if def == 0 {
+		def = l.Max
+	}
+
+	safeValue := value
+	switch {
+	case value == 0 && def != 0:
+		safeValue = def
+	case l.Max != 0 && value > l.Max:
+		safeValue = l.Max
+	}
+
+	if safeValue == value {
+		return value
+	}
+
+	if s.cfg.Modes[rule] == SafetyModeValidate {
+		if value == 0 {
+			res.Violations = append(res.Violations, fmt.Sprintf("%s is missing, expected %d", field, safeValue))
+		} else {
+			res.Violations = append(res.Violations, fmt.Sprintf("%s %d is not safe, expected %d", field, value, safeValue))
+		}
+		return value
+	}
+
+	if value == 0 {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s has been set to %d", field, safeValue))
+	} else {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s %d has been changed to %d", field, value, safeValue))
+	}
+	return safeValue
+}
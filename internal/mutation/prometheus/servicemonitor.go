@@ -2,43 +2,51 @@ package prometheus
 
 import (
 	"context"
-	"time"
+	"fmt"
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 )
 
 // ServiceMonitorSafer will ensure the service monitor has safe settings, and mutate them instead.
 type ServiceMonitorSafer interface {
-	EnsureSafety(ctx context.Context, sm *monitoringv1.ServiceMonitor) error
+	EnsureSafety(ctx context.Context, sm *monitoringv1.ServiceMonitor) (*Result, error)
 }
 
 type serviceMonitorSafer struct {
-	minScrapeInterval time.Duration
+	safer
 }
 
 // NewServiceMonitorSafer returns a new ServiceMonitorSafer that will mutate
-// the received service monitor in case it don0't have safe settings. Current checks:
+// the received service monitor in case it don't have safe settings. Current checks:
 // - Minimum scrape interval.
-func NewServiceMonitorSafer(minScrapeInterval time.Duration) ServiceMonitorSafer {
-	return serviceMonitorSafer{minScrapeInterval: minScrapeInterval}
-}
+// - Default, minimum and maximum scrape timeout.
+// - Scrape timeout not greater than the scrape interval.
+// - Default and maximum sample, target, label, label name length and label value length limits.
+//
+// The rules in validate mode will not mutate the service monitor, instead the unsafe
+// settings will be returned as violations.
+func NewServiceMonitorSafer(config SaferConfig) (ServiceMonitorSafer, error) {
+	s, err := newSafer(config)
+	if err != nil {
+		return nil, err
+	}
 
-func (s serviceMonitorSafer) EnsureSafety(_ context.Context, sm *monitoringv1.ServiceMonitor) error {
-	endpoints := make([]monitoringv1.Endpoint, 0, len(sm.Spec.Endpoints))
+	return serviceMonitorSafer{safer: s}, nil
+}
 
-	for _, e := range sm.Spec.Endpoints {
-		// Set safe/correct scrape intervals if required.
-		t, err := time.ParseDuration(e.Interval)
-		if err != nil || t < s.minScrapeInterval {
-			e.Interval = s.minScrapeInterval.String()
-		}
+func (s serviceMonitorSafer) EnsureSafety(_ context.Context, sm *monitoringv1.ServiceMonitor) (*Result, error) {
+	res := &Result{}
 
+	endpoints := make([]monitoringv1.Endpoint, 0, len(sm.Spec.Endpoints))
+	for i, e := range sm.Spec.Endpoints {
+		e.Interval, e.ScrapeTimeout = s.safeScrapeSettings(res, fmt.Sprintf("endpoint %d ", i), e.Interval, e.ScrapeTimeout)
 		endpoints = append(endpoints, e)
 	}
-
 	sm.Spec.Endpoints = endpoints
 
-	return nil
+	s.safeLimits(res, &sm.Spec.SampleLimit, &sm.Spec.TargetLimit, &sm.Spec.LabelLimit, &sm.Spec.LabelNameLengthLimit, &sm.Spec.LabelValueLengthLimit)
+
+	return res, nil
 }
 
 // DummyServiceMonitorSafer is a ServiceMonitorSafer that doesn't do anything.
@@ -48,6 +56,6 @@ type dummyServiceMonitorSafer int
 
 var _ ServiceMonitorSafer = DummyServiceMonitorSafer
 
-func (dummyServiceMonitorSafer) EnsureSafety(ctx context.Context, sm *monitoringv1.ServiceMonitor) error {
-	return nil
+func (dummyServiceMonitorSafer) EnsureSafety(ctx context.Context, sm *monitoringv1.ServiceMonitor) (*Result, error) {
+	return &Result{}, nil
 }
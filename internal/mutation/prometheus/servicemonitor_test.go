@@ -14,12 +14,14 @@ import (
 
 func TestServiceMonitorSafer(t *testing.T) {
 	tests := map[string]struct {
-		minScrapeInterval time.Duration
-		servMon           *monitoringv1.ServiceMonitor
-		expServMon        *monitoringv1.ServiceMonitor
+		config        prometheus.SaferConfig
+		servMon       *monitoringv1.ServiceMonitor
+		expServMon    *monitoringv1.ServiceMonitor
+		expWarnings   []string
+		expViolations []string
 	}{
 		"Having a correct scrape interval should not mutate the service monitor.": {
-			minScrapeInterval: 10 * time.Second,
+			config: prometheus.SaferConfig{MinScrapeInterval: 10 * time.Second},
 			servMon: &monitoringv1.ServiceMonitor{
 				Spec: monitoringv1.ServiceMonitorSpec{
 					Endpoints: []monitoringv1.Endpoint{
@@ -41,7 +43,7 @@ func TestServiceMonitorSafer(t *testing.T) {
 		},
 
 		"Having a incorrect scrape interval should not mutate the service monitor.": {
-			minScrapeInterval: 16 * time.Second,
+			config: prometheus.SaferConfig{MinScrapeInterval: 16 * time.Second},
 			servMon: &monitoringv1.ServiceMonitor{
 				Spec: monitoringv1.ServiceMonitorSpec{
 					Endpoints: []monitoringv1.Endpoint{
@@ -60,10 +62,11 @@ func TestServiceMonitorSafer(t *testing.T) {
 					},
 				},
 			},
+			expWarnings: []string{`endpoint 1 interval "15s" has been changed to "16s"`},
 		},
 
 		"Having a service monitor without interval should set the minimum one.": {
-			minScrapeInterval: 11 * time.Second,
+			config: prometheus.SaferConfig{MinScrapeInterval: 11 * time.Second},
 			servMon: &monitoringv1.ServiceMonitor{
 				Spec: monitoringv1.ServiceMonitorSpec{
 					Endpoints: []monitoringv1.Endpoint{
@@ -84,6 +87,238 @@ func TestServiceMonitorSafer(t *testing.T) {
 					},
 				},
 			},
+			expWarnings: []string{`endpoint 3 interval has been set to "11s"`},
+		},
+
+		"Having a service monitor without timeout should set the default one.": {
+			config: prometheus.SaferConfig{DefaultScrapeTimeout: 10 * time.Second},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "30s"},
+						{Interval: "30s", ScrapeTimeout: "5s"},
+					},
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "30s", ScrapeTimeout: "10s"},
+						{Interval: "30s", ScrapeTimeout: "5s"},
+					},
+				},
+			},
+			expWarnings: []string{`endpoint 0 scrapeTimeout has been set to "10s"`},
+		},
+
+		"Having a service monitor with timeouts out of range should set the minimum and maximum ones.": {
+			config: prometheus.SaferConfig{
+				MinScrapeTimeout: 5 * time.Second,
+				MaxScrapeTimeout: 20 * time.Second,
+			},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "1m", ScrapeTimeout: "1s"},
+						{Interval: "1m", ScrapeTimeout: "50s"},
+						{Interval: "1m", ScrapeTimeout: "10s"},
+						{Interval: "1m"},
+					},
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "1m", ScrapeTimeout: "5s"},
+						{Interval: "1m", ScrapeTimeout: "20s"},
+						{Interval: "1m", ScrapeTimeout: "10s"},
+						{Interval: "1m"},
+					},
+				},
+			},
+			expWarnings: []string{
+				`endpoint 0 scrapeTimeout "1s" has been changed to "5s"`,
+				`endpoint 1 scrapeTimeout "50s" has been changed to "20s"`,
+			},
+		},
+
+		"Having a service monitor with a timeout greater than the corrected interval should set the interval as the timeout.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval:    15 * time.Second,
+				DefaultScrapeTimeout: 20 * time.Second,
+			},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "10s", ScrapeTimeout: "12s"},
+						{Interval: "5s"},
+						{Interval: "30s", ScrapeTimeout: "40s"},
+					},
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "15s", ScrapeTimeout: "12s"},
+						{Interval: "15s", ScrapeTimeout: "15s"},
+						{Interval: "30s", ScrapeTimeout: "30s"},
+					},
+				},
+			},
+			expWarnings: []string{
+				`endpoint 0 interval "10s" has been changed to "15s"`,
+				`endpoint 1 interval "5s" has been changed to "15s"`,
+				`endpoint 1 scrapeTimeout has been set to "15s"`,
+				`endpoint 2 scrapeTimeout "40s" has been changed to "30s"`,
+			},
+		},
+
+		"Having a service monitor with Prometheus durations should be parsed and formatted in the Prometheus format.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval: 90 * time.Second,
+				MaxScrapeTimeout:  time.Minute,
+			},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "1d", ScrapeTimeout: "1h"},
+						{Interval: "1m", ScrapeTimeout: "1m"},
+					},
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "1d", ScrapeTimeout: "1m"},
+						{Interval: "1m30s", ScrapeTimeout: "1m"},
+					},
+				},
+			},
+			expWarnings: []string{
+				`endpoint 0 scrapeTimeout "1h" has been changed to "1m"`,
+				`endpoint 1 interval "1m" has been changed to "1m30s"`,
+			},
+		},
+
+		"Having fractional safe durations should be set in the Prometheus format.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval: 2500 * time.Millisecond,
+				MinScrapeTimeout:  1500 * time.Millisecond,
+			},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "1s", ScrapeTimeout: "1s"},
+					},
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "2s500ms", ScrapeTimeout: "1s500ms"},
+					},
+				},
+			},
+			expWarnings: []string{
+				`endpoint 0 interval "1s" has been changed to "2s500ms"`,
+				`endpoint 0 scrapeTimeout "1s" has been changed to "1s500ms"`,
+			},
+		},
+
+		"Having a service monitor without timeout nor default timeout and a short interval should set the interval as the timeout.": {
+			config: prometheus.SaferConfig{},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "5s"},
+						{Interval: "30s"},
+					},
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "5s", ScrapeTimeout: "5s"},
+						{Interval: "30s"},
+					},
+				},
+			},
+			expWarnings: []string{`endpoint 0 scrapeTimeout has been set to "5s"`},
+		},
+
+		"Having a service monitor with unset and out of range limits should set the defaults and maximums.": {
+			config: prometheus.SaferConfig{
+				SampleLimit:           prometheus.Limit{Default: 1000, Max: 5000},
+				TargetLimit:           prometheus.Limit{Max: 50},
+				LabelLimit:            prometheus.Limit{Default: 30, Max: 60},
+				LabelNameLengthLimit:  prometheus.Limit{Max: 200},
+				LabelValueLengthLimit: prometheus.Limit{Default: 500},
+			},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					LabelLimit:            100,
+					LabelNameLengthLimit:  150,
+					LabelValueLengthLimit: 2000,
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints:             []monitoringv1.Endpoint{},
+					SampleLimit:           1000,
+					TargetLimit:           50,
+					LabelLimit:            60,
+					LabelNameLengthLimit:  150,
+					LabelValueLengthLimit: 2000,
+				},
+			},
+			expWarnings: []string{
+				"sampleLimit has been set to 1000",
+				"targetLimit has been set to 50",
+				"labelLimit 100 has been changed to 60",
+			},
+		},
+
+		"Having rules in validate mode, the unsafe settings should be reported as violations without mutating them.": {
+			config: prometheus.SaferConfig{
+				MinScrapeInterval:    15 * time.Second,
+				DefaultScrapeTimeout: 10 * time.Second,
+				SampleLimit:          prometheus.Limit{Max: 5000},
+				TargetLimit:          prometheus.Limit{Max: 50},
+				Modes: map[prometheus.SafetyRule]prometheus.SafetyMode{
+					prometheus.SafetyRuleScrapeInterval: prometheus.SafetyModeValidate,
+					prometheus.SafetyRuleSampleLimit:    prometheus.SafetyModeValidate,
+					prometheus.SafetyRuleTargetLimit:    prometheus.SafetyModeMutate,
+				},
+			},
+			servMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "5s"},
+						{},
+					},
+					SampleLimit: 10000,
+				},
+			},
+			expServMon: &monitoringv1.ServiceMonitor{
+				Spec: monitoringv1.ServiceMonitorSpec{
+					Endpoints: []monitoringv1.Endpoint{
+						{Interval: "5s", ScrapeTimeout: "5s"},
+						{ScrapeTimeout: "10s"},
+					},
+					SampleLimit: 10000,
+					TargetLimit: 50,
+				},
+			},
+			expWarnings: []string{
+				`endpoint 0 scrapeTimeout has been set to "5s"`,
+				`endpoint 1 scrapeTimeout has been set to "10s"`,
+				"targetLimit has been set to 50",
+			},
+			expViolations: []string{
+				`endpoint 0 interval "5s" is not safe, expected "15s"`,
+				`endpoint 1 interval is missing, expected "15s"`,
+				"sampleLimit 10000 is not safe, expected 5000",
+			},
 		},
 	}
 
@@ -92,11 +327,56 @@ func TestServiceMonitorSafer(t *testing.T) {
 			assert := assert.New(t)
 			require := require.New(t)
 
-			s := prometheus.NewServiceMonitorSafer(test.minScrapeInterval)
-			err := s.EnsureSafety(context.TODO(), test.servMon)
+			s, err := prometheus.NewServiceMonitorSafer(test.config)
+			require.NoError(err)
+
+			res, err := s.EnsureSafety(context.TODO(), test.servMon)
 			require.NoError(err)
 
 			assert.Equal(test.expServMon, test.servMon)
+			assert.Equal(test.expWarnings, res.Warnings)
+			assert.Equal(test.expViolations, res.Violations)
+		})
+	}
+}
+
+func TestNewServiceMonitorSaferInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		config prometheus.SaferConfig
+	}{
+		"A minimum scrape timeout greater than the maximum should be invalid.": {
+			config: prometheus.SaferConfig{MinScrapeTimeout: 20 * time.Second, MaxScrapeTimeout: 10 * time.Second},
+		},
+
+		"A default scrape timeout less than the minimum should be invalid.": {
+			config: prometheus.SaferConfig{MinScrapeTimeout: 10 * time.Second, DefaultScrapeTimeout: 5 * time.Second},
+		},
+
+		"A default limit greater than the maximum should be invalid.": {
+			config: prometheus.SaferConfig{SampleLimit: prometheus.Limit{Default: 100, Max: 10}},
+		},
+
+		"A default scrape timeout greater than the maximum should be invalid.": {
+			config: prometheus.SaferConfig{MaxScrapeTimeout: 10 * time.Second, DefaultScrapeTimeout: 15 * time.Second},
+		},
+
+		"An unknown safety rule should be invalid.": {
+			config: prometheus.SaferConfig{
+				Modes: map[prometheus.SafetyRule]prometheus.SafetyMode{"unknown": prometheus.SafetyModeValidate},
+			},
+		},
+
+		"An invalid safety mode should be invalid.": {
+			config: prometheus.SaferConfig{
+				Modes: map[prometheus.SafetyRule]prometheus.SafetyMode{prometheus.SafetyRuleSampleLimit: "wrong"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := prometheus.NewServiceMonitorSafer(test.config)
+			assert.Error(t, err)
 		})
 	}
 }
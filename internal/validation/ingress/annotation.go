@@ -0,0 +1,98 @@
+package ingress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnotationPolicy is the policy of the annotations an ingress can have.
+type AnnotationPolicy struct {
+	// Forbidden are the annotation keys that ingresses can't have (e.g: `nginx.ingress.kubernetes.io/server-snippet`).
+	Forbidden []string
+	// AllowedValues are the regexes the annotation values must match, by annotation key. The regexes
+	// need to match the full value.
+	AllowedValues map[string]string
+	// Required are the annotation keys that ingresses must have.
+	Required []string
+}
+
+// NewAnnotationValidator returns a new validator that checks the ingress annotations
+// using an annotation policy. All the violating annotations will be returned at once.
+// It knows how to handle different ingress types.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func NewAnnotationValidator(policy AnnotationPolicy) (Validator, error) {
+	forbidden := map[string]bool{}
+	for _, k := range policy.Forbidden {
+		forbidden[k] = true
+	}
+
+	allowedValues := map[string]*regexp.Regexp{}
+	for k, r := range policy.AllowedValues {
+		// Anchor the regex so it needs to match the full value.
+		rc, err := regexp.Compile(`^(?:` + r + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("the '%s' regex of %s annotation is not valid: %w", r, k, err)
+		}
+		allowedValues[k] = rc
+	}
+
+	return annotationValidator{
+		forbidden:     forbidden,
+		allowedValues: allowedValues,
+		required:      policy.Required,
+	}, nil
+}
+
+type annotationValidator struct {
+	forbidden     map[string]bool
+	allowedValues map[string]*regexp.Regexp
+	required      []string
+}
+
+func (a annotationValidator) Validate(ctx context.Context, obj metav1.Object) error {
+	// Check is an ingress before doing anything.
+	_, err := getHosts(obj)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+
+	// Sort the keys so the violations are deterministic.
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	errs := []string{}
+	for _, k := range keys {
+		if a.forbidden[k] {
+			errs = append(errs, fmt.Sprintf("annotation %s is forbidden", k))
+			continue
+		}
+
+		regex, ok := a.allowedValues[k]
+		if ok && !regex.MatchString(annotations[k]) {
+			errs = append(errs, fmt.Sprintf("annotation %s value %q is not allowed", k, annotations[k]))
+		}
+	}
+
+	for _, k := range a.required {
+		if _, ok := annotations[k]; !ok {
+			errs = append(errs, fmt.Sprintf("annotation %s is required", k))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package ingress_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+func newAnnotationsIngress(annotations map[string]string) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+	}
+}
+
+func TestAnnotationValidator(t *testing.T) {
+	policy := ingress.AnnotationPolicy{
+		Forbidden: []string{
+			"nginx.ingress.kubernetes.io/configuration-snippet",
+			"nginx.ingress.kubernetes.io/server-snippet",
+		},
+		AllowedValues: map[string]string{
+			"nginx.ingress.kubernetes.io/ssl-redirect":  "true|false",
+			"nginx.ingress.kubernetes.io/proxy-timeout": `[0-9]+`,
+		},
+		Required: []string{"team"},
+	}
+
+	tests := map[string]struct {
+		policy    ingress.AnnotationPolicy
+		ingress   metav1.Object
+		expErr    bool
+		expErrMsg string
+	}{
+		"Having a non ingress should return an error.": {
+			ingress: &extensionsv1beta1.Deployment{},
+			expErr:  true,
+		},
+
+		"Having an ingress (extensions/v1beta1) without policy, it should be valid.": {
+			ingress: &extensionsv1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"nginx.ingress.kubernetes.io/server-snippet": "test"},
+				},
+			},
+		},
+
+		"Having an ingress with correct annotations, it should be valid.": {
+			policy: policy,
+			ingress: newAnnotationsIngress(map[string]string{
+				"team": "team-a",
+				"nginx.ingress.kubernetes.io/ssl-redirect":  "true",
+				"nginx.ingress.kubernetes.io/proxy-timeout": "30",
+				"other": "test",
+			}),
+		},
+
+		"Having an ingress with a forbidden annotation, it should be invalid.": {
+			policy: policy,
+			ingress: newAnnotationsIngress(map[string]string{
+				"team": "team-a",
+				"nginx.ingress.kubernetes.io/server-snippet": "test",
+			}),
+			expErr: true,
+		},
+
+		"Having an ingress with a not allowed annotation value, it should be invalid.": {
+			policy: policy,
+			ingress: newAnnotationsIngress(map[string]string{
+				"team": "team-a",
+				"nginx.ingress.kubernetes.io/ssl-redirect": "maybe",
+			}),
+			expErr: true,
+		},
+
+		"Having an ingress with a value that partially matches the allowed regex, it should be invalid.": {
+			policy: policy,
+			ingress: newAnnotationsIngress(map[string]string{
+				"team": "team-a",
+				"nginx.ingress.kubernetes.io/ssl-redirect": "falsey",
+			}),
+			expErr: true,
+		},
+
+		"Having an ingress without a required annotation, it should be invalid.": {
+			policy:  policy,
+			ingress: newAnnotationsIngress(nil),
+			expErr:  true,
+		},
+
+		"Having an ingress with multiple violations, it should return all of them.": {
+			policy: policy,
+			ingress: newAnnotationsIngress(map[string]string{
+				"nginx.ingress.kubernetes.io/configuration-snippet": "test",
+				"nginx.ingress.kubernetes.io/server-snippet":        "test",
+				"nginx.ingress.kubernetes.io/ssl-redirect":          "maybe",
+			}),
+			expErr: true,
+			expErrMsg: "annotation nginx.ingress.kubernetes.io/configuration-snippet is forbidden, " +
+				"annotation nginx.ingress.kubernetes.io/server-snippet is forbidden, " +
+				`annotation nginx.ingress.kubernetes.io/ssl-redirect value "maybe" is not allowed, ` +
+				"annotation team is required",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			validator, err := ingress.NewAnnotationValidator(test.policy)
+			require.NoError(err)
+
+			err = validator.Validate(context.TODO(), test.ingress)
+
+			if test.expErr {
+				assert.Error(err)
+				if test.expErrMsg != "" {
+					assert.EqualError(err, test.expErrMsg)
+				}
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestNewAnnotationValidatorInvalidRegex(t *testing.T) {
+	_, err := ingress.NewAnnotationValidator(ingress.AnnotationPolicy{
+		AllowedValues: map[string]string{"test": "[a-z"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package ingress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// regexChars are the characters that make a path look like a regex.
+const regexChars = `^$*+?()[]{}|\`
+
+// NewBackendValidator returns a new validator that checks the ingress backends and paths:
+// - All the paths have a path type.
+// - There are no duplicated host and path pairs.
+// - The paths with `Exact` path type don't look like a regex.
+// - Optionally, the ingress doesn't have a default backend.
+// All the violations will be returned at once.
+// It knows how to handle different ingress types.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func NewBackendValidator(forbidDefaultBackend bool) Validator {
+	return backendValidator{forbidDefaultBackend: forbidDefaultBackend}
+}
+
+type backendValidator struct {
+	forbidDefaultBackend bool
+}
+
+func (b backendValidator) Validate(ctx context.Context, obj metav1.Object) error {
+	rps, err := getRulePaths(obj)
+	if err != nil {
+		return err
+	}
+
+	defaultBackend, err := hasDefaultBackend(obj)
+	if err != nil {
+		return err
+	}
+
+	errs := []string{}
+	if b.forbidDefaultBackend && defaultBackend {
+		errs = append(errs, "default backend is not allowed")
+	}
+
+	seen := map[string]bool{}
+	for _, rp := range rps {
+		hp := rp.host + rp.path
+		if rp.pathType == "" {
+			errs = append(errs, fmt.Sprintf("path %s is missing path type", hp))
+		}
+
+		if rp.pathType == string(networkingv1.PathTypeExact) && strings.ContainsAny(rp.path, regexChars) {
+			errs = append(errs, fmt.Sprintf("path %s looks like a regex and has %s path type", hp, rp.pathType))
+		}
+
+		if seen[hp] {
+			errs = append(errs, fmt.Sprintf("path %s is duplicated", hp))
+		}
+		seen[hp] = true
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package ingress_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+func newPathsIngress(host string, paths ...networkingv1.HTTPIngressPath) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBackendValidator(t *testing.T) {
+	exact := networkingv1.PathTypeExact
+	prefix := networkingv1.PathTypePrefix
+	extPrefix := extensionsv1beta1.PathTypePrefix
+
+	tests := map[string]struct {
+		forbidDefaultBackend bool
+		ingress              metav1.Object
+		expErr               bool
+		expErrMsg            string
+	}{
+		"Having a non ingress should return an error.": {
+			ingress: &extensionsv1beta1.Deployment{},
+			expErr:  true,
+		},
+
+		"Having an ingress (extensions/v1beta1) with correct paths, it should be valid.": {
+			ingress: &extensionsv1beta1.Ingress{
+				Spec: extensionsv1beta1.IngressSpec{
+					Rules: []extensionsv1beta1.IngressRule{
+						{
+							Host: "test1.slok.dev",
+							IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+								HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
+									Paths: []extensionsv1beta1.HTTPIngressPath{{Path: "/", PathType: &extPrefix}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"Having an ingress (networking/v1beta1) without path type, it should be invalid.": {
+			ingress: &networkingv1beta1.Ingress{
+				Spec: networkingv1beta1.IngressSpec{
+					Rules: []networkingv1beta1.IngressRule{
+						{
+							Host: "test1.slok.dev",
+							IngressRuleValue: networkingv1beta1.IngressRuleValue{
+								HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+									Paths: []networkingv1beta1.HTTPIngressPath{{Path: "/"}},
+								},
+							},
+						},
+					},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress with correct paths, it should be valid.": {
+			ingress: newPathsIngress("test1.slok.dev",
+				networkingv1.HTTPIngressPath{Path: "/a", PathType: &prefix},
+				networkingv1.HTTPIngressPath{Path: "/b", PathType: &exact},
+			),
+		},
+
+		"Having an ingress with duplicated paths, it should be invalid.": {
+			ingress: newPathsIngress("test1.slok.dev",
+				networkingv1.HTTPIngressPath{Path: "/a", PathType: &prefix},
+				networkingv1.HTTPIngressPath{Path: "/a", PathType: &exact},
+			),
+			expErr: true,
+		},
+
+		"Having an ingress with a regex path with exact path type, it should be invalid.": {
+			ingress: newPathsIngress("test1.slok.dev",
+				networkingv1.HTTPIngressPath{Path: "/a/.*", PathType: &exact},
+			),
+			expErr: true,
+		},
+
+		"Having an ingress with a default backend, it should be valid.": {
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{DefaultBackend: &networkingv1.IngressBackend{}},
+			},
+		},
+
+		"Having an ingress with a default backend and forbidding default backends, it should be invalid.": {
+			forbidDefaultBackend: true,
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{DefaultBackend: &networkingv1.IngressBackend{}},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress (extensions/v1beta1) with a default backend and forbidding default backends, it should be invalid.": {
+			forbidDefaultBackend: true,
+			ingress: &extensionsv1beta1.Ingress{
+				Spec: extensionsv1beta1.IngressSpec{Backend: &extensionsv1beta1.IngressBackend{}},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress with multiple violations, it should return all of them.": {
+			forbidDefaultBackend: true,
+			ingress: func() *networkingv1.Ingress {
+				ing := newPathsIngress("test1.slok.dev",
+					networkingv1.HTTPIngressPath{Path: "/a"},
+					networkingv1.HTTPIngressPath{Path: "/b(.*)", PathType: &exact},
+					networkingv1.HTTPIngressPath{Path: "/c", PathType: &prefix},
+					networkingv1.HTTPIngressPath{Path: "/c", PathType: &prefix},
+				)
+				ing.Spec.DefaultBackend = &networkingv1.IngressBackend{}
+				return ing
+			}(),
+			expErr:    true,
+			expErrMsg: "default backend is not allowed, path test1.slok.dev/a is missing path type, path test1.slok.dev/b(.*) looks like a regex and has Exact path type, path test1.slok.dev/c is duplicated",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := ingress.NewBackendValidator(test.forbidDefaultBackend).Validate(context.TODO(), test.ingress)
+
+			if test.expErr {
+				assert.Error(err)
+				if test.expErrMsg != "" {
+					assert.EqualError(err, test.expErrMsg)
+				}
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
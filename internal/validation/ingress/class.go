@@ -0,0 +1,154 @@
+package ingress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LegacyClassAnnotation is the deprecated annotation used to set the ingress class.
+const LegacyClassAnnotation = "kubernetes.io/ingress.class"
+
+// ClassValidatorConfig is the configuration of the ingress class validator.
+type ClassValidatorConfig struct {
+	// AllowedClasses are the ingress classes that can be used, if empty, any class can be used.
+	AllowedClasses []string
+	// HostClasses pin the hosts matching host patterns to an ingress class (e.g public
+	// hosts only on the public class), check `NewHostPolicyValidator` for the supported
+	// patterns (deny patterns are not supported, use a more specific pattern instead).
+	HostClasses map[string]string
+}
+
+// NewClassValidator returns a new validator that checks the ingress class, set using `spec.ingressClassName`
+// or the legacy `kubernetes.io/ingress.class` annotation, is allowed:
+// - If there are allowed classes, the ingress must have one of these.
+// - If the class is set in both places, they must be the same.
+// - The hosts matching a host class pattern must use that class.
+// When multiple host class patterns match a host, the most specific one is used (exact, wildcard
+// and suffix, the longest first), so exceptions can be made (e.g: `*.example.com=public` and
+// `internal.example.com=internal`), the wildcard hosts must use the class of all the patterns
+// they overlap.
+// It knows how to handle different ingress types.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func NewClassValidator(config ClassValidatorConfig) (Validator, error) {
+	allowed := map[string]bool{}
+	for _, c := range config.AllowedClasses {
+		allowed[c] = true
+	}
+
+	patterns := make([]string, 0, len(config.HostClasses))
+	for p := range config.HostClasses {
+		patterns = append(patterns, p)
+	}
+
+	hostClasses := make([]hostClass, 0, len(patterns))
+	for _, p := range patterns {
+		// A deny pattern doesn't pin any host to a class.
+		if strings.HasPrefix(p, "!") {
+			return nil, fmt.Errorf("the '%s' host pattern is not valid: deny patterns are not supported on host classes", p)
+		}
+
+		hp, err := newHostPattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("the '%s' host pattern is not valid: %w", p, err)
+		}
+
+		class := config.HostClasses[p]
+		if len(allowed) > 0 && !allowed[class] {
+			return nil, fmt.Errorf("the '%s' host pattern class %q is not an allowed class", p, class)
+		}
+
+		hostClasses = append(hostClasses, hostClass{pattern: hp, class: class})
+	}
+
+	// Sort the patterns from the most specific to the least specific one (exact, wildcard and
+	// suffix, the longest first), so the most specific pattern decides the class of a host.
+	sort.Slice(hostClasses, func(i, j int) bool {
+		pi, pj := hostClasses[i].pattern, hostClasses[j].pattern
+		if pi.kind != pj.kind {
+			return pi.kind < pj.kind
+		}
+		if len(pi.labels) != len(pj.labels) {
+			return len(pi.labels) > len(pj.labels)
+		}
+		return pi.raw < pj.raw
+	})
+
+	return classValidator{
+		allowed:     allowed,
+		hostClasses: hostClasses,
+	}, nil
+}
+
+type hostClass struct {
+	pattern hostPattern
+	class   string
+}
+
+type classValidator struct {
+	allowed     map[string]bool
+	hostClasses []hostClass
+}
+
+func (c classValidator) Validate(ctx context.Context, obj metav1.Object) error {
+	specClass, err := getClassName(obj)
+	if err != nil {
+		return err
+	}
+
+	hosts, err := getHosts(obj)
+	if err != nil {
+		return err
+	}
+
+	annotationClass := obj.GetAnnotations()[LegacyClassAnnotation]
+	if specClass != "" && annotationClass != "" && specClass != annotationClass {
+		return fmt.Errorf("ingress class name %q and %s annotation %q don't match", specClass, LegacyClassAnnotation, annotationClass)
+	}
+
+	class := specClass
+	if class == "" {
+		class = annotationClass
+	}
+
+	errs := []string{}
+	if len(c.allowed) > 0 {
+		switch {
+		case class == "":
+			errs = append(errs, "ingress class is required")
+		case !c.allowed[class]:
+			errs = append(errs, fmt.Sprintf("ingress class %q is not allowed", class))
+		}
+	}
+
+	for _, host := range hosts {
+		labels := splitHost(host)
+		wildcardHost := len(labels) > 0 && labels[0] == "*"
+		for _, hc := range c.hostClasses {
+			if !hc.pattern.overlaps(labels) {
+				continue
+			}
+
+			if hc.class != class {
+				errs = append(errs, fmt.Sprintf("host %s requires %q ingress class", host, hc.class))
+				break
+			}
+
+			// The most specific pattern decides the class of a host, but the wildcard hosts
+			// include the hosts of all the overlapping patterns.
+			if !wildcardHost {
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package ingress_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+func newClassIngress(specClass, annotationClass string, hosts ...string) *networkingv1.Ingress {
+	ing := newHostsIngress(hosts...)
+	if specClass != "" {
+		ing.Spec.IngressClassName = &specClass
+	}
+	if annotationClass != "" {
+		ing.Annotations = map[string]string{ingress.LegacyClassAnnotation: annotationClass}
+	}
+	return ing
+}
+
+func TestClassValidator(t *testing.T) {
+	public := "public"
+
+	tests := map[string]struct {
+		config  ingress.ClassValidatorConfig
+		ingress metav1.Object
+		expErr  bool
+	}{
+		"Having a non ingress should return an error.": {
+			ingress: &extensionsv1beta1.Deployment{},
+			expErr:  true,
+		},
+
+		"Having an ingress (extensions/v1beta1) with an allowed class, it should be valid.": {
+			config: ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: &extensionsv1beta1.Ingress{
+				Spec: extensionsv1beta1.IngressSpec{IngressClassName: &public},
+			},
+		},
+
+		"Having an ingress (networking/v1beta1) with an allowed class, it should be valid.": {
+			config: ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: &networkingv1beta1.Ingress{
+				Spec: networkingv1beta1.IngressSpec{IngressClassName: &public},
+			},
+		},
+
+		"Having an ingress with an allowed class, it should be valid.": {
+			config:  ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: newClassIngress("internal", "", "test1.slok.dev"),
+		},
+
+		"Having an ingress with a not allowed class, it should be invalid.": {
+			config:  ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: newClassIngress("other", "", "test1.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress with an allowed class on the legacy annotation, it should be valid.": {
+			config:  ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: newClassIngress("", "internal", "test1.slok.dev"),
+		},
+
+		"Having an ingress with a not allowed class on the legacy annotation, it should be invalid.": {
+			config:  ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: newClassIngress("", "other", "test1.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress with different classes on the spec and the legacy annotation, it should be invalid.": {
+			config:  ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: newClassIngress("public", "internal", "test1.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress without class and allowed classes, it should be invalid.": {
+			config:  ingress.ClassValidatorConfig{AllowedClasses: []string{"public", "internal"}},
+			ingress: newClassIngress("", "", "test1.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress without class and without allowed classes, it should be valid.": {
+			ingress: newClassIngress("", "", "test1.slok.dev"),
+		},
+
+		"Having an ingress with a host pinned to its class, it should be valid.": {
+			config: ingress.ClassValidatorConfig{
+				AllowedClasses: []string{"public", "internal"},
+				HostClasses:    map[string]string{".slok.dev": "public"},
+			},
+			ingress: newClassIngress("public", "", "test1.slok.dev"),
+		},
+
+		"Having an ingress with a host pinned to other class, it should be invalid.": {
+			config: ingress.ClassValidatorConfig{
+				AllowedClasses: []string{"public", "internal"},
+				HostClasses:    map[string]string{".slok.dev": "public"},
+			},
+			ingress: newClassIngress("internal", "", "test1.slok.internal", "test1.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress with a host not pinned to any class, it should be valid.": {
+			config: ingress.ClassValidatorConfig{
+				AllowedClasses: []string{"public", "internal"},
+				HostClasses:    map[string]string{".slok.dev": "public"},
+			},
+			ingress: newClassIngress("internal", "", "test1.slok.internal"),
+		},
+
+		"Having an ingress with a wildcard host that overlaps a pinned host, it should be invalid.": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{"test1.slok.dev": "public"},
+			},
+			ingress: newClassIngress("internal", "", "*.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress with a host matching multiple pinned patterns, the most specific one should be used (exact).": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{"*.slok.dev": "public", "internal.slok.dev": "internal"},
+			},
+			ingress: newClassIngress("internal", "", "internal.slok.dev"),
+		},
+
+		"Having an ingress with a host matching multiple pinned patterns, the less specific ones should not be used.": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{"*.slok.dev": "public", "internal.slok.dev": "internal"},
+			},
+			ingress: newClassIngress("public", "", "internal.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress with a host matching multiple pinned patterns, the most specific one should be used (wildcard).": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{"*.slok.dev": "public", ".slok.dev": "internal"},
+			},
+			ingress: newClassIngress("public", "", "test1.slok.dev"),
+		},
+
+		"Having an ingress with a host matching multiple pinned patterns, the most specific one should be used (longest suffix).": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{".slok.dev": "public", ".internal.slok.dev": "internal"},
+			},
+			ingress: newClassIngress("internal", "", "a.b.internal.slok.dev"),
+		},
+
+		"Having an ingress with a wildcard host that overlaps pinned patterns of different classes, it should be invalid.": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{"*.slok.dev": "public", "internal.slok.dev": "internal"},
+			},
+			ingress: newClassIngress("public", "", "*.slok.dev"),
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			validator, err := ingress.NewClassValidator(test.config)
+			require.NoError(err)
+
+			err = validator.Validate(context.TODO(), test.ingress)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestNewClassValidatorInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		config    ingress.ClassValidatorConfig
+		expErrMsg string
+	}{
+		"An invalid host pattern should be invalid.": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{"test1.*.slok.dev": "public"},
+			},
+		},
+
+		"A host class with a not allowed class should be invalid.": {
+			config: ingress.ClassValidatorConfig{
+				AllowedClasses: []string{"internal"},
+				HostClasses:    map[string]string{".slok.dev": "public"},
+			},
+		},
+
+		"A host class with a deny pattern should be invalid.": {
+			config: ingress.ClassValidatorConfig{
+				HostClasses: map[string]string{"!.slok.dev": "public"},
+			},
+			expErrMsg: "deny patterns are not supported on host classes",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ingress.NewClassValidator(test.config)
+			if assert.Error(t, err) && test.expErrMsg != "" {
+				assert.Contains(t, err.Error(), test.expErrMsg)
+			}
+		})
+	}
+}
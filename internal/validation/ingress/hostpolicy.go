@@ -0,0 +1,192 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewHostPolicyValidator returns a new validator that checks an ingress hosts using DNS aware
+// host patterns, matching always on full label boundaries. The supported patterns are:
+// - `example.com`: Exact host.
+// - `*.example.com`: Wildcard, any single label subdomain (e.g `a.example.com` but not `a.b.example.com`).
+// - `.example.com`: Suffix, any subdomain at any depth (e.g `a.example.com` and `a.b.example.com`).
+// - `!<pattern>`: Deny, any host matching the pattern will be invalid, even if it matches an allowed one.
+//
+// If there are no allowed patterns, all hosts that are not denied will be valid.
+// It knows how to handle different ingress types.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func NewHostPolicyValidator(hostPatterns []string) (Validator, error) {
+	v := hostPolicyValidator{}
+	for _, p := range hostPatterns {
+		deny := strings.HasPrefix(p, "!")
+		hp, err := newHostPattern(strings.TrimPrefix(p, "!"))
+		if err != nil {
+			return nil, fmt.Errorf("the '%s' host pattern is not valid: %w", p, err)
+		}
+
+		if deny {
+			v.deny = append(v.deny, hp)
+		} else {
+			v.allow = append(v.allow, hp)
+		}
+	}
+
+	return v, nil
+}
+
+type hostPolicyValidator struct {
+	allow []hostPattern
+	deny  []hostPattern
+}
+
+func (h hostPolicyValidator) Validate(ctx context.Context, obj metav1.Object) error {
+	hosts, err := getHosts(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		err := h.validateHost(host)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h hostPolicyValidator) validateHost(host string) error {
+	labels := splitHost(host)
+
+	// An empty host matches all the hosts, so is only safe when we don't have any restriction.
+	if len(labels) == 0 {
+		if len(h.allow) > 0 || len(h.deny) > 0 {
+			return fmt.Errorf("empty host is not allowed")
+		}
+		return nil
+	}
+
+	for _, p := range h.deny {
+		if p.overlaps(labels) {
+			return fmt.Errorf("host %s is denied by %s host pattern", host, p)
+		}
+	}
+
+	if len(h.allow) == 0 {
+		return nil
+	}
+
+	for _, p := range h.allow {
+		if p.matches(labels) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("host %s is not a valid host", host)
+}
+
+type hostPatternKind int
+
+const (
+	hostPatternKindExact hostPatternKind = iota
+	hostPatternKindWildcard
+	hostPatternKindSuffix
+)
+
+// hostPattern is a parsed DNS aware host pattern.
+type hostPattern struct {
+	raw    string
+	kind   hostPatternKind
+	labels []string // Labels of the pattern without the wildcard/suffix part.
+}
+
+func newHostPattern(pattern string) (hostPattern, error) {
+	hp := hostPattern{raw: pattern, kind: hostPatternKindExact}
+
+	p := pattern
+	switch {
+	case strings.HasPrefix(p, "*."):
+		hp.kind = hostPatternKindWildcard
+		p = strings.TrimPrefix(p, "*.")
+	case strings.HasPrefix(p, "."):
+		hp.kind = hostPatternKindSuffix
+		p = strings.TrimPrefix(p, ".")
+	}
+
+	hp.labels = splitHost(p)
+	if len(hp.labels) == 0 {
+		return hostPattern{}, fmt.Errorf("empty host pattern")
+	}
+
+	for _, l := range hp.labels {
+		if l == "" {
+			return hostPattern{}, fmt.Errorf("empty DNS label")
+		}
+		if strings.Contains(l, "*") {
+			return hostPattern{}, fmt.Errorf("wildcard is only supported as the first full label")
+		}
+	}
+
+	return hp, nil
+}
+
+func (h hostPattern) String() string { return h.raw }
+
+// matches returns true if the host labels match the pattern.
+func (h hostPattern) matches(hostLabels []string) bool {
+	return h.match(hostLabels, false)
+}
+
+// overlaps returns true if any of the hosts represented by the host labels could match the
+// pattern. This is different from matches when the host is a wildcard (e.g `*.example.com`).
+func (h hostPattern) overlaps(hostLabels []string) bool {
+	return h.match(hostLabels, true)
+}
+
+func (h hostPattern) match(hostLabels []string, wildcardHost bool) bool {
+	// Get the number of labels the host must have before our pattern labels.
+	prefixLen := len(hostLabels) - len(h.labels)
+	switch h.kind {
+	case hostPatternKindExact:
+		if prefixLen != 0 {
+			return false
+		}
+	case hostPatternKindWildcard:
+		if prefixLen != 1 {
+			return false
+		}
+	case hostPatternKindSuffix:
+		if prefixLen < 1 {
+			return false
+		}
+	}
+
+	for i, l := range h.labels {
+		hl := hostLabels[prefixLen+i]
+		if hl == l {
+			continue
+		}
+
+		// A wildcard host label could be any label.
+		if wildcardHost && hl == "*" {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// splitHost normalizes a host and splits it in DNS labels.
+func splitHost(host string) []string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" {
+		return nil
+	}
+
+	return strings.Split(host, ".")
+}
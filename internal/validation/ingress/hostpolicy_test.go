@@ -0,0 +1,188 @@
+package ingress_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+func newHostsIngress(hosts ...string) *networkingv1.Ingress {
+	ing := &networkingv1.Ingress{}
+	for _, h := range hosts {
+		ing.Spec.Rules = append(ing.Spec.Rules, networkingv1.IngressRule{Host: h})
+	}
+	return ing
+}
+
+func TestHostPolicyValidator(t *testing.T) {
+	tests := map[string]struct {
+		hostPatterns []string
+		ingress      metav1.Object
+		expErr       bool
+	}{
+		"Having a non ingress should return an error.": {
+			ingress: &extensionsv1beta1.Deployment{},
+			expErr:  true,
+		},
+
+		"Having an ingress (extensions/v1beta1) and not host patterns all ingresses should be valid.": {
+			ingress: &extensionsv1beta1.Ingress{
+				Spec: extensionsv1beta1.IngressSpec{
+					Rules: []extensionsv1beta1.IngressRule{
+						{Host: "test1.slok.dev"},
+					},
+				},
+			},
+		},
+
+		"Having an ingress (networking/v1beta1) and a matching exact pattern, it should be valid.": {
+			hostPatterns: []string{"test1.slok.dev"},
+			ingress: &networkingv1beta1.Ingress{
+				Spec: networkingv1beta1.IngressSpec{
+					Rules: []networkingv1beta1.IngressRule{
+						{Host: "test1.slok.dev"},
+					},
+				},
+			},
+		},
+
+		"Having an ingress and a non matching exact pattern, it should be invalid.": {
+			hostPatterns: []string{"test1.slok.dev"},
+			ingress:      newHostsIngress("test2.slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress and an exact pattern, the match should be case insensitive and ignore the root dot.": {
+			hostPatterns: []string{"Test1.Slok.dev."},
+			ingress:      newHostsIngress("test1.slok.DEV"),
+		},
+
+		"Having an ingress and a wildcard pattern, a single label subdomain should be valid.": {
+			hostPatterns: []string{"*.slok.dev"},
+			ingress:      newHostsIngress("test1.slok.dev"),
+		},
+
+		"Having an ingress and a wildcard pattern, a multiple label subdomain should be invalid.": {
+			hostPatterns: []string{"*.slok.dev"},
+			ingress:      newHostsIngress("a.test1.slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress and a wildcard pattern, the apex domain should be invalid.": {
+			hostPatterns: []string{"*.slok.dev"},
+			ingress:      newHostsIngress("slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress and a wildcard pattern, the match should be on label boundaries.": {
+			hostPatterns: []string{"*.slok.dev"},
+			ingress:      newHostsIngress("evil-slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress and a suffix pattern, a multiple label subdomain should be valid.": {
+			hostPatterns: []string{".slok.dev"},
+			ingress:      newHostsIngress("a.b.slok.dev", "test1.slok.dev"),
+		},
+
+		"Having an ingress and a suffix pattern, the match should be on label boundaries.": {
+			hostPatterns: []string{".slok.dev"},
+			ingress:      newHostsIngress("test1.evil-slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress and a suffix pattern, the apex domain should be invalid.": {
+			hostPatterns: []string{".slok.dev"},
+			ingress:      newHostsIngress("slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress with multiple hosts and multiple patterns, if all match it should be valid.": {
+			hostPatterns: []string{"slok.dev", "*.slok.dev", ".slok.right"},
+			ingress:      newHostsIngress("slok.dev", "test1.slok.dev", "a.b.slok.right"),
+		},
+
+		"Having an ingress with multiple hosts and multiple patterns, if one doesn't match it should be invalid.": {
+			hostPatterns: []string{"slok.dev", "*.slok.dev", ".slok.right"},
+			ingress:      newHostsIngress("slok.dev", "test1.slok.dev", "a.b.slok.wrong"),
+			expErr:       true,
+		},
+
+		"Having an ingress and a deny pattern, a matching host should be invalid even if allowed.": {
+			hostPatterns: []string{".slok.dev", "!admin.slok.dev"},
+			ingress:      newHostsIngress("test1.slok.dev", "admin.slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress and only deny patterns, a non denied host should be valid.": {
+			hostPatterns: []string{"!admin.slok.dev"},
+			ingress:      newHostsIngress("test1.slok.dev"),
+		},
+
+		"Having an ingress with a wildcard host that overlaps with a denied host, it should be invalid.": {
+			hostPatterns: []string{".slok.dev", "!admin.slok.dev"},
+			ingress:      newHostsIngress("*.slok.dev"),
+			expErr:       true,
+		},
+
+		"Having an ingress with a wildcard host and a wildcard pattern, it should be valid.": {
+			hostPatterns: []string{"*.slok.dev"},
+			ingress:      newHostsIngress("*.slok.dev"),
+		},
+
+		"Having an ingress with an empty host and patterns, it should be invalid.": {
+			hostPatterns: []string{".slok.dev"},
+			ingress:      newHostsIngress(""),
+			expErr:       true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			validator, err := ingress.NewHostPolicyValidator(test.hostPatterns)
+			require.NoError(err)
+
+			err = validator.Validate(context.TODO(), test.ingress)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestNewHostPolicyValidatorInvalidPatterns(t *testing.T) {
+	tests := map[string]struct {
+		hostPattern string
+	}{
+		"Empty pattern should be invalid.":                {hostPattern: ""},
+		"Empty deny pattern should be invalid.":           {hostPattern: "!"},
+		"Empty labels should be invalid.":                 {hostPattern: "test1..slok.dev"},
+		"Wildcard on a middle label should be invalid.":   {hostPattern: "test1.*.slok.dev"},
+		"Partial wildcard label should be invalid.":       {hostPattern: "test*.slok.dev"},
+		"Wildcard without domain should be invalid.":      {hostPattern: "*."},
+		"Suffix without domain should be invalid.":        {hostPattern: "."},
+		"Multiple wildcard labels should be invalid.":     {hostPattern: "*.*.slok.dev"},
+		"Wildcard and suffix together should be invalid.": {hostPattern: "*..slok.dev"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ingress.NewHostPolicyValidator([]string{test.hostPattern})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IngressEventHandler knows how to handle the events of the ingresses on the cluster.
+type IngressEventHandler interface {
+	// AddOrUpdateIngress handles an ingress that has been added or updated.
+	AddOrUpdateIngress(obj metav1.Object)
+	// DeleteIngress handles an ingress that has been deleted.
+	DeleteIngress(obj metav1.Object)
+}
+
+// IngressWatcher knows how to watch the ingresses of the cluster in an informer style.
+type IngressWatcher interface {
+	// Watch will send all the existing ingresses and the next changes to the handler until the
+	// context is done. It will return once the existing ingresses have been sent to the handler.
+	Watch(ctx context.Context, h IngressEventHandler) error
+}
+
+// ContextWithOldIngress returns a new context with the previous version of the validated ingress,
+// on updates the validators can use it to only validate the changes of the ingress.
+func ContextWithOldIngress(ctx context.Context, obj metav1.Object) context.Context {
+	return context.WithValue(ctx, oldIngressCtxKey{}, obj)
+}
+
+type oldIngressCtxKey struct{}
+
+func oldIngressFromContext(ctx context.Context) metav1.Object {
+	obj, _ := ctx.Value(oldIngressCtxKey{}).(metav1.Object)
+	return obj
+}
+
+// NewHostUniquenessValidator returns a new validator that checks an ingress doesn't claim a host
+// and path that is already claimed by another ingress on the cluster. The paths are compared
+// without the trailing slash and the `Prefix` paths claim all the paths under them (e.g `/api`
+// claims `/api/v1`), the rules without paths claim all the host.
+// The validator keeps an in-memory index of all the host and path claims of the cluster ingresses
+// using the received watcher, it will stop watching when the context is done.
+// When multiple ingresses claim the same host and path, the owner is the oldest one (by creation
+// timestamp and then by namespace and name), so it's the same on every replica and restart.
+// On updates (the old ingress is on the context, see `ContextWithOldIngress`) only the new claims
+// of the ingress are validated, so already conflicting ingresses can still be updated (e.g: labels
+// or finalizers).
+// It knows how to handle different ingress types.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func NewHostUniquenessValidator(ctx context.Context, watcher IngressWatcher) (Validator, error) {
+	v := &hostUniquenessValidator{
+		hosts:     map[string]map[string]bool{},
+		ingresses: map[string]ingressClaims{},
+	}
+
+	err := watcher.Watch(ctx, v)
+	if err != nil {
+		return nil, fmt.Errorf("could not watch ingresses: %w", err)
+	}
+
+	return v, nil
+}
+
+// ingressClaims are the host path claims of an ingress.
+type ingressClaims struct {
+	created   time.Time
+	hostPaths []hostPath
+}
+
+type hostUniquenessValidator struct {
+	mu        sync.RWMutex
+	hosts     map[string]map[string]bool // Host to the ingress IDs claiming paths on it.
+	ingresses map[string]ingressClaims   // Ingress ID to its host path claims.
+}
+
+func (h *hostUniquenessValidator) Validate(ctx context.Context, obj metav1.Object) error {
+	hps, err := getHostPaths(obj)
+	if err != nil {
+		return err
+	}
+
+	// The host paths already claimed by the ingress before the update are not validated.
+	oldClaims := map[hostPath]bool{}
+	if old := oldIngressFromContext(ctx); old != nil {
+		oldHps, err := getHostPaths(old)
+		if err != nil {
+			return err
+		}
+		for _, hp := range oldHps {
+			oldClaims[hp] = true
+		}
+	}
+
+	id := ingressID(obj)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	conflicts := []string{}
+	seen := map[hostPath]bool{}
+	for _, hp := range hps {
+		if oldClaims[hp] || seen[hp] {
+			continue
+		}
+		seen[hp] = true
+
+		owner := h.owner(hp, id)
+		if owner == "" {
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s (claimed by %s)", hp, owner))
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("host paths already claimed by other ingresses: %s", strings.Join(conflicts, ", "))
+	}
+
+	return nil
+}
+
+// owner returns the owner of the host path claims ignoring the received ingress, the owner is the
+// oldest ingress with an overlapping host path claim (e.g `/api` prefix and `/api/v1`) and the ID is
+// used to untie. Returns empty if there is no other ingress claiming it.
+// It's not thread safe.
+func (h *hostUniquenessValidator) owner(hp hostPath, ignoreID string) string {
+	owner := ""
+	for id := range h.hosts[hp.host] {
+		if id == ignoreID || !h.claimsOverlap(id, hp) {
+			continue
+		}
+
+		if owner == "" {
+			owner = id
+			continue
+		}
+
+		created, ownerCreated := h.ingresses[id].created, h.ingresses[owner].created
+		if created.Before(ownerCreated) || (created.Equal(ownerCreated) && id < owner) {
+			owner = id
+		}
+	}
+
+	return owner
+}
+
+// claimsOverlap returns true if any of the host path claims of the ingress overlaps the host path.
+// It's not thread safe.
+func (h *hostUniquenessValidator) claimsOverlap(id string, hp hostPath) bool {
+	for _, claim := range h.ingresses[id].hostPaths {
+		if claim.overlaps(hp) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *hostUniquenessValidator) AddOrUpdateIngress(obj metav1.Object) {
+	hps, err := getHostPaths(obj)
+	if err != nil {
+		return
+	}
+
+	id := ingressID(obj)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.deleteClaims(id)
+
+	claims := []hostPath{}
+	seen := map[hostPath]bool{}
+	for _, hp := range hps {
+		if seen[hp] {
+			continue
+		}
+		seen[hp] = true
+
+		if h.hosts[hp.host] == nil {
+			h.hosts[hp.host] = map[string]bool{}
+		}
+		h.hosts[hp.host][id] = true
+		claims = append(claims, hp)
+	}
+
+	h.ingresses[id] = ingressClaims{
+		created:   obj.GetCreationTimestamp().Time,
+		hostPaths: claims,
+	}
+}
+
+func (h *hostUniquenessValidator) DeleteIngress(obj metav1.Object) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.deleteClaims(ingressID(obj))
+}
+
+// deleteClaims deletes the host path claims of an ingress, it's not thread safe.
+func (h *hostUniquenessValidator) deleteClaims(id string) {
+	for _, hp := range h.ingresses[id].hostPaths {
+		delete(h.hosts[hp.host], id)
+		if len(h.hosts[hp.host]) == 0 {
+			delete(h.hosts, hp.host)
+		}
+	}
+	delete(h.ingresses, id)
+}
+
+func ingressID(obj metav1.Object) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,266 @@
+package ingress_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+// testIngressWatcher is a fake watcher that sends the existing ingresses and
+// lets the tests send the next events.
+type testIngressWatcher struct {
+	existing []metav1.Object
+	handler  ingress.IngressEventHandler
+}
+
+func (t *testIngressWatcher) Watch(_ context.Context, h ingress.IngressEventHandler) error {
+	t.handler = h
+	for _, obj := range t.existing {
+		h.AddOrUpdateIngress(obj)
+	}
+	return nil
+}
+
+func newHostPathIngress(ns, name string, hostPaths map[string][]string) *networkingv1.Ingress {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name},
+	}
+	for host, paths := range hostPaths {
+		rule := networkingv1.IngressRule{Host: host}
+		if len(paths) > 0 {
+			rule.HTTP = &networkingv1.HTTPIngressRuleValue{}
+			for _, p := range paths {
+				rule.HTTP.Paths = append(rule.HTTP.Paths, networkingv1.HTTPIngressPath{Path: p})
+			}
+		}
+		ing.Spec.Rules = append(ing.Spec.Rules, rule)
+	}
+	return ing
+}
+
+func newPathTypeIngress(ns, name, host, path string, pathType networkingv1.PathType) *networkingv1.Ingress {
+	ing := newHostPathIngress(ns, name, map[string][]string{host: {path}})
+	ing.Spec.Rules[0].HTTP.Paths[0].PathType = &pathType
+	return ing
+}
+
+func newCreatedHostPathIngress(ns, name string, created time.Time, hostPaths map[string][]string) *networkingv1.Ingress {
+	ing := newHostPathIngress(ns, name, hostPaths)
+	ing.CreationTimestamp = metav1.NewTime(created)
+	return ing
+}
+
+func TestHostUniquenessValidator(t *testing.T) {
+	t0 := time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)
+	existing := []metav1.Object{
+		newHostPathIngress("ns1", "ing1", map[string][]string{"test1.slok.dev": {"/a", "/b"}}),
+		newHostPathIngress("ns2", "ing2", map[string][]string{"test2.slok.dev": nil}),
+		// Already conflicting ingresses, the newest one is received first.
+		newCreatedHostPathIngress("ns6", "ing6", t0.Add(time.Hour), map[string][]string{"test5.slok.dev": {"/a"}}),
+		newCreatedHostPathIngress("ns5", "ing5", t0, map[string][]string{"test5.slok.dev": {"/a"}}),
+		newPathTypeIngress("ns8", "ing8", "test8.slok.dev", "/api", networkingv1.PathTypePrefix),
+	}
+
+	tests := map[string]struct {
+		events     func(h ingress.IngressEventHandler)
+		oldIngress metav1.Object
+		ingress    metav1.Object
+		expErr     bool
+		expErrMsg  string
+	}{
+		"Having a non ingress should return an error.": {
+			ingress: &extensionsv1beta1.Deployment{},
+			expErr:  true,
+		},
+
+		"Having an ingress with a not claimed host, it should be valid.": {
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test3.slok.dev": {"/a"}}),
+		},
+
+		"Having an ingress with a claimed host but a not claimed path, it should be valid.": {
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test1.slok.dev": {"/c"}}),
+		},
+
+		"Having an ingress with a host and path claimed by another ingress, it should be invalid.": {
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test1.slok.dev": {"/c", "/b"}}),
+			expErr:  true,
+		},
+
+		"Having an ingress with a host and path claimed by another ingress with the same name on other namespace, it should be invalid.": {
+			ingress: newHostPathIngress("ns3", "ing1", map[string][]string{"test1.slok.dev": {"/a"}}),
+			expErr:  true,
+		},
+
+		"Having an ingress without paths on a host claimed without paths, it should be invalid.": {
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test2.slok.dev": nil}),
+			expErr:  true,
+		},
+
+		"Having an ingress with the root path on a host claimed without paths, it should be invalid.": {
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test2.slok.dev": {"/"}}),
+			expErr:  true,
+		},
+
+		"Having an ingress with a host and path claimed by another ingress with a trailing slash, it should be invalid.": {
+			ingress:   newHostPathIngress("ns3", "ing3", map[string][]string{"test1.slok.dev": {"/a/"}}),
+			expErr:    true,
+			expErrMsg: "host paths already claimed by other ingresses: test1.slok.dev/a (claimed by ns1/ing1)",
+		},
+
+		"Having an ingress with an exact path under a prefix path claimed by another ingress, it should be invalid.": {
+			ingress:   newPathTypeIngress("ns3", "ing3", "test8.slok.dev", "/api/v1", networkingv1.PathTypeExact),
+			expErr:    true,
+			expErrMsg: "host paths already claimed by other ingresses: test8.slok.dev/api/v1 (claimed by ns8/ing8)",
+		},
+
+		"Having an ingress with a prefix path that contains a path claimed by another ingress, it should be invalid.": {
+			ingress:   newPathTypeIngress("ns3", "ing3", "test1.slok.dev", "/", networkingv1.PathTypePrefix),
+			expErr:    true,
+			expErrMsg: "host paths already claimed by other ingresses: test1.slok.dev/ (claimed by ns1/ing1)",
+		},
+
+		"Having an ingress with a path that only shares the string prefix of a prefix path claimed by another ingress, it should be valid.": {
+			ingress: newPathTypeIngress("ns3", "ing3", "test8.slok.dev", "/apis", networkingv1.PathTypeExact),
+		},
+
+		"Having an ingress (extensions/v1beta1) with a host and path claimed by another ingress, it should be invalid.": {
+			ingress: &extensionsv1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns3", Name: "ing3"},
+				Spec: extensionsv1beta1.IngressSpec{
+					Rules: []extensionsv1beta1.IngressRule{
+						{Host: "test2.slok.dev"},
+					},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress (networking/v1beta1) with a host and path claimed by another ingress, it should be invalid.": {
+			ingress: &networkingv1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns3", Name: "ing3"},
+				Spec: networkingv1beta1.IngressSpec{
+					Rules: []networkingv1beta1.IngressRule{
+						{Host: "TEST2.slok.dev"},
+					},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an update of an ingress with its own host and paths, it should be valid.": {
+			ingress: newHostPathIngress("ns1", "ing1", map[string][]string{"test1.slok.dev": {"/a", "/b", "/c"}}),
+		},
+
+		"Having an ingress with a host and path claimed by a deleted ingress, it should be valid.": {
+			events: func(h ingress.IngressEventHandler) {
+				h.DeleteIngress(newHostPathIngress("ns1", "ing1", nil))
+			},
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test1.slok.dev": {"/a"}}),
+		},
+
+		"Having an ingress with a host and path released by an ingress update, it should be valid.": {
+			events: func(h ingress.IngressEventHandler) {
+				h.AddOrUpdateIngress(newHostPathIngress("ns1", "ing1", map[string][]string{"test1.slok.dev": {"/b"}}))
+			},
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test1.slok.dev": {"/a"}}),
+		},
+
+		"Having an ingress with a host and path claimed by a new ingress, it should be invalid.": {
+			events: func(h ingress.IngressEventHandler) {
+				h.AddOrUpdateIngress(newHostPathIngress("ns4", "ing4", map[string][]string{"test4.slok.dev": {"/a"}}))
+			},
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test4.slok.dev": {"/a"}}),
+			expErr:  true,
+		},
+
+		"Having multiple ingresses claiming the same host and path, when the owner is deleted the next one should be the owner.": {
+			events: func(h ingress.IngressEventHandler) {
+				h.AddOrUpdateIngress(newHostPathIngress("ns4", "ing4", map[string][]string{"test2.slok.dev": nil}))
+				h.DeleteIngress(newHostPathIngress("ns2", "ing2", nil))
+			},
+			ingress: newHostPathIngress("ns3", "ing3", map[string][]string{"test2.slok.dev": nil}),
+			expErr:  true,
+		},
+
+		"Having multiple ingresses claiming the same host and path, the next owner should be valid after the owner is deleted.": {
+			events: func(h ingress.IngressEventHandler) {
+				h.AddOrUpdateIngress(newHostPathIngress("ns4", "ing4", map[string][]string{"test2.slok.dev": nil}))
+				h.DeleteIngress(newHostPathIngress("ns2", "ing2", nil))
+			},
+			ingress: newHostPathIngress("ns4", "ing4", map[string][]string{"test2.slok.dev": nil}),
+		},
+
+		"Having already conflicting ingresses, the oldest one should be the owner.": {
+			ingress:   newHostPathIngress("ns3", "ing3", map[string][]string{"test5.slok.dev": {"/a"}}),
+			expErr:    true,
+			expErrMsg: "host paths already claimed by other ingresses: test5.slok.dev/a (claimed by ns5/ing5)",
+		},
+
+		"Having already conflicting ingresses with the same creation, the owner should be untied by namespace and name.": {
+			events: func(h ingress.IngressEventHandler) {
+				h.AddOrUpdateIngress(newCreatedHostPathIngress("ns8", "ing8", t0, map[string][]string{"test6.slok.dev": nil}))
+				h.AddOrUpdateIngress(newCreatedHostPathIngress("ns7", "ing7", t0, map[string][]string{"test6.slok.dev": nil}))
+			},
+			ingress:   newHostPathIngress("ns3", "ing3", map[string][]string{"test6.slok.dev": nil}),
+			expErr:    true,
+			expErrMsg: "host paths already claimed by other ingresses: test6.slok.dev/ (claimed by ns7/ing7)",
+		},
+
+		"Having already conflicting ingresses, an update of the not owner without new host paths should be valid.": {
+			oldIngress: newCreatedHostPathIngress("ns6", "ing6", t0.Add(time.Hour), map[string][]string{"test5.slok.dev": {"/a"}}),
+			ingress:    newCreatedHostPathIngress("ns6", "ing6", t0.Add(time.Hour), map[string][]string{"test5.slok.dev": {"/a"}, "test7.slok.dev": nil}),
+		},
+
+		"Having already conflicting ingresses, an update of the not owner with new claimed host paths should be invalid.": {
+			oldIngress: newCreatedHostPathIngress("ns6", "ing6", t0.Add(time.Hour), map[string][]string{"test5.slok.dev": {"/a"}}),
+			ingress:    newCreatedHostPathIngress("ns6", "ing6", t0.Add(time.Hour), map[string][]string{"test5.slok.dev": {"/a"}, "test1.slok.dev": {"/a"}}),
+			expErr:     true,
+			expErrMsg:  "host paths already claimed by other ingresses: test1.slok.dev/a (claimed by ns1/ing1)",
+		},
+
+		"Having already conflicting ingresses, a validation of the not owner without the old ingress should be invalid.": {
+			ingress:   newCreatedHostPathIngress("ns6", "ing6", t0.Add(time.Hour), map[string][]string{"test5.slok.dev": {"/a"}}),
+			expErr:    true,
+			expErrMsg: "host paths already claimed by other ingresses: test5.slok.dev/a (claimed by ns5/ing5)",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			watcher := &testIngressWatcher{existing: existing}
+			validator, err := ingress.NewHostUniquenessValidator(context.TODO(), watcher)
+			require.NoError(err)
+
+			if test.events != nil {
+				test.events(watcher.handler)
+			}
+
+			ctx := context.TODO()
+			if test.oldIngress != nil {
+				ctx = ingress.ContextWithOldIngress(ctx, test.oldIngress)
+			}
+			err = validator.Validate(ctx, test.ingress)
+
+			if test.expErr {
+				assert.Error(err)
+				if test.expErrMsg != "" {
+					assert.EqualError(err, test.expErrMsg)
+				}
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
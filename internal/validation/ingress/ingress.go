@@ -3,7 +3,11 @@ package ingress
 import (
 	"context"
 	"errors"
+	"strings"
 
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -21,3 +25,248 @@ var DummyValidator Validator = dummyValidator(0)
 type dummyValidator int
 
 func (dummyValidator) Validate(_ context.Context, _ metav1.Object) error { return nil }
+
+// getHosts returns the hosts of the ingress rules.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func getHosts(obj metav1.Object) ([]string, error) {
+	hosts := []string{}
+
+	// Missing generics...
+	switch ing := obj.(type) {
+	case *extensionsv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			hosts = append(hosts, r.Host)
+		}
+	case *networkingv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			hosts = append(hosts, r.Host)
+		}
+	case *networkingv1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			hosts = append(hosts, r.Host)
+		}
+	default:
+		return nil, ErrNotIngress
+	}
+
+	return hosts, nil
+}
+
+// hostPath is a host and path claimed by an ingress rule.
+type hostPath struct {
+	host string
+	path string
+	// prefix is true when the path claims all the paths under it (e.g `/api` claims `/api/v1`).
+	prefix bool
+}
+
+func (h hostPath) String() string { return h.host + h.path }
+
+// overlaps returns true if both host paths could receive the same requests.
+func (h hostPath) overlaps(o hostPath) bool {
+	if h.host != o.host {
+		return false
+	}
+
+	return h.path == o.path || (h.prefix && isSubPath(o.path, h.path)) || (o.prefix && isSubPath(h.path, o.path))
+}
+
+// isSubPath returns true if the path is under the prefix path, matching on full path elements.
+func isSubPath(path, prefix string) bool {
+	return prefix == "/" || strings.HasPrefix(path, prefix+"/")
+}
+
+// getHostPaths returns the host and paths of the ingress rules, the paths are normalized without
+// the trailing slash. The `Prefix` paths claim all the paths under them, the rest of the path types
+// (`Exact` and `ImplementationSpecific`) only claim their path. The rules without paths will be
+// returned with the `/` prefix path (all the host).
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func getHostPaths(obj metav1.Object) ([]hostPath, error) {
+	hps := []hostPath{}
+	add := func(host string, paths []hostPath) {
+		if len(paths) == 0 {
+			paths = []hostPath{{path: "/", prefix: true}}
+		}
+		for _, p := range paths {
+			p.host = strings.ToLower(host)
+			p.path = strings.TrimRight(p.path, "/")
+			if p.path == "" {
+				p.path = "/"
+			}
+			hps = append(hps, p)
+		}
+	}
+
+	// Missing generics...
+	switch ing := obj.(type) {
+	case *extensionsv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			paths := []hostPath{}
+			if r.HTTP != nil {
+				for _, p := range r.HTTP.Paths {
+					prefix := p.PathType != nil && *p.PathType == extensionsv1beta1.PathTypePrefix
+					paths = append(paths, hostPath{path: p.Path, prefix: prefix})
+				}
+			}
+			add(r.Host, paths)
+		}
+	case *networkingv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			paths := []hostPath{}
+			if r.HTTP != nil {
+				for _, p := range r.HTTP.Paths {
+					prefix := p.PathType != nil && *p.PathType == networkingv1beta1.PathTypePrefix
+					paths = append(paths, hostPath{path: p.Path, prefix: prefix})
+				}
+			}
+			add(r.Host, paths)
+		}
+	case *networkingv1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			paths := []hostPath{}
+			if r.HTTP != nil {
+				for _, p := range r.HTTP.Paths {
+					prefix := p.PathType != nil && *p.PathType == networkingv1.PathTypePrefix
+					paths = append(paths, hostPath{path: p.Path, prefix: prefix})
+				}
+			}
+			add(r.Host, paths)
+		}
+	default:
+		return nil, ErrNotIngress
+	}
+
+	return hps, nil
+}
+
+// ingressTLS is an ingress TLS entry.
+type ingressTLS struct {
+	hosts      []string
+	secretName string
+}
+
+// getTLS returns the TLS entries of the ingress.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func getTLS(obj metav1.Object) ([]ingressTLS, error) {
+	tlss := []ingressTLS{}
+
+	// Missing generics...
+	switch ing := obj.(type) {
+	case *extensionsv1beta1.Ingress:
+		for _, t := range ing.Spec.TLS {
+			tlss = append(tlss, ingressTLS{hosts: t.Hosts, secretName: t.SecretName})
+		}
+	case *networkingv1beta1.Ingress:
+		for _, t := range ing.Spec.TLS {
+			tlss = append(tlss, ingressTLS{hosts: t.Hosts, secretName: t.SecretName})
+		}
+	case *networkingv1.Ingress:
+		for _, t := range ing.Spec.TLS {
+			tlss = append(tlss, ingressTLS{hosts: t.Hosts, secretName: t.SecretName})
+		}
+	default:
+		return nil, ErrNotIngress
+	}
+
+	return tlss, nil
+}
+
+// rulePath is an ingress rule HTTP path.
+type rulePath struct {
+	host     string
+	path     string
+	pathType string // Empty if missing.
+}
+
+// getRulePaths returns the HTTP paths of the ingress rules.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func getRulePaths(obj metav1.Object) ([]rulePath, error) {
+	rps := []rulePath{}
+
+	// Missing generics...
+	switch ing := obj.(type) {
+	case *extensionsv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			if r.HTTP == nil {
+				continue
+			}
+			for _, p := range r.HTTP.Paths {
+				rp := rulePath{host: r.Host, path: p.Path}
+				if p.PathType != nil {
+					rp.pathType = string(*p.PathType)
+				}
+				rps = append(rps, rp)
+			}
+		}
+	case *networkingv1beta1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			if r.HTTP == nil {
+				continue
+			}
+			for _, p := range r.HTTP.Paths {
+				rp := rulePath{host: r.Host, path: p.Path}
+				if p.PathType != nil {
+					rp.pathType = string(*p.PathType)
+				}
+				rps = append(rps, rp)
+			}
+		}
+	case *networkingv1.Ingress:
+		for _, r := range ing.Spec.Rules {
+			if r.HTTP == nil {
+				continue
+			}
+			for _, p := range r.HTTP.Paths {
+				rp := rulePath{host: r.Host, path: p.Path}
+				if p.PathType != nil {
+					rp.pathType = string(*p.PathType)
+				}
+				rps = append(rps, rp)
+			}
+		}
+	default:
+		return nil, ErrNotIngress
+	}
+
+	return rps, nil
+}
+
+// hasDefaultBackend returns true if the ingress has a default backend.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func hasDefaultBackend(obj metav1.Object) (bool, error) {
+	// Missing generics...
+	switch ing := obj.(type) {
+	case *extensionsv1beta1.Ingress:
+		return ing.Spec.Backend != nil, nil
+	case *networkingv1beta1.Ingress:
+		return ing.Spec.Backend != nil, nil
+	case *networkingv1.Ingress:
+		return ing.Spec.DefaultBackend != nil, nil
+	}
+
+	return false, ErrNotIngress
+}
+
+// getClassName returns the ingress class name set on the ingress spec, empty if missing.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func getClassName(obj metav1.Object) (string, error) {
+	var className *string
+
+	// Missing generics...
+	switch ing := obj.(type) {
+	case *extensionsv1beta1.Ingress:
+		className = ing.Spec.IngressClassName
+	case *networkingv1beta1.Ingress:
+		className = ing.Spec.IngressClassName
+	case *networkingv1.Ingress:
+		className = ing.Spec.IngressClassName
+	default:
+		return "", ErrNotIngress
+	}
+
+	if className == nil {
+		return "", nil
+	}
+
+	return *className, nil
+}
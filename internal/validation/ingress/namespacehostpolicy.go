@@ -0,0 +1,171 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceLabelsGetter knows how to get the labels of a namespace.
+type NamespaceLabelsGetter interface {
+	GetNamespaceLabels(ctx context.Context, namespace string) (map[string]string, error)
+}
+
+// NamespaceHostPolicy are the host patterns allowed on the namespaces that match by name
+// or by namespace label selector.
+type NamespaceHostPolicy struct {
+	// Namespaces are the namespace names that will match the policy.
+	Namespaces []string
+	// NamespaceSelector is a Kubernetes label selector (e.g `tenant=team-a,env in (prod,staging)`)
+	// that will match the policy with the namespace labels.
+	NamespaceSelector string
+	// HostPatterns are the host patterns used on the matched namespaces, check
+	// `NewHostPolicyValidator` for the supported patterns. Required.
+	HostPatterns []string
+}
+
+// NamespaceHostPolicyValidatorConfig is the configuration of the namespace host policy validator.
+type NamespaceHostPolicyValidatorConfig struct {
+	// Policies are the namespace host policies, the first policy that matches the namespace will be used.
+	Policies []NamespaceHostPolicy
+	// DefaultHostPatterns are the host patterns used when no policy matches the namespace. Required.
+	DefaultHostPatterns []string
+	// NamespaceLabelsGetter is used to get the namespace labels when using namespace selectors.
+	NamespaceLabelsGetter NamespaceLabelsGetter
+}
+
+func (c *NamespaceHostPolicyValidatorConfig) defaults() error {
+	// Without host patterns all the hosts would be allowed.
+	for i, p := range c.Policies {
+		if len(p.HostPatterns) == 0 {
+			return fmt.Errorf("policy %d host patterns are required", i)
+		}
+	}
+
+	if len(c.DefaultHostPatterns) == 0 {
+		return fmt.Errorf("default host patterns are required")
+	}
+
+	if c.NamespaceLabelsGetter == nil {
+		for _, p := range c.Policies {
+			if p.NamespaceSelector != "" {
+				return fmt.Errorf("namespace labels getter is required when using namespace selectors")
+			}
+		}
+	}
+
+	return nil
+}
+
+// NewNamespaceHostPolicyValidator returns a new validator that checks an ingress hosts using
+// different host policies based on the namespace of the ingress.
+// It knows how to handle different ingress types.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func NewNamespaceHostPolicyValidator(config NamespaceHostPolicyValidatorConfig) (Validator, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	policies := make([]namespaceHostPolicy, 0, len(config.Policies))
+	for i, p := range config.Policies {
+		if len(p.Namespaces) == 0 && p.NamespaceSelector == "" {
+			return nil, fmt.Errorf("policy %d requires namespaces or a namespace selector", i)
+		}
+
+		var selector labels.Selector
+		if p.NamespaceSelector != "" {
+			selector, err = labels.Parse(p.NamespaceSelector)
+			if err != nil {
+				return nil, fmt.Errorf("policy %d namespace selector is not valid: %w", i, err)
+			}
+		}
+
+		v, err := NewHostPolicyValidator(p.HostPatterns)
+		if err != nil {
+			return nil, fmt.Errorf("policy %d is not valid: %w", i, err)
+		}
+
+		namespaces := map[string]struct{}{}
+		for _, ns := range p.Namespaces {
+			namespaces[ns] = struct{}{}
+		}
+
+		policies = append(policies, namespaceHostPolicy{
+			namespaces: namespaces,
+			selector:   selector,
+			validator:  v,
+		})
+	}
+
+	defaultValidator, err := NewHostPolicyValidator(config.DefaultHostPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("default policy is not valid: %w", err)
+	}
+
+	return namespaceHostPolicyValidator{
+		policies:         policies,
+		defaultValidator: defaultValidator,
+		nsLabelsGetter:   config.NamespaceLabelsGetter,
+	}, nil
+}
+
+type namespaceHostPolicy struct {
+	namespaces map[string]struct{}
+	selector   labels.Selector
+	validator  Validator
+}
+
+type namespaceHostPolicyValidator struct {
+	policies         []namespaceHostPolicy
+	defaultValidator Validator
+	nsLabelsGetter   NamespaceLabelsGetter
+}
+
+func (n namespaceHostPolicyValidator) Validate(ctx context.Context, obj metav1.Object) error {
+	// Check is an ingress before doing anything.
+	_, err := getHosts(obj)
+	if err != nil {
+		return err
+	}
+
+	v, err := n.validatorFor(ctx, obj.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	return v.Validate(ctx, obj)
+}
+
+// validatorFor returns the host policy validator of the first policy that matches the namespace.
+func (n namespaceHostPolicyValidator) validatorFor(ctx context.Context, namespace string) (Validator, error) {
+	var nsLabels labels.Set
+	nsLabelsLoaded := false
+	for _, p := range n.policies {
+		if _, ok := p.namespaces[namespace]; ok {
+			return p.validator, nil
+		}
+
+		if p.selector == nil {
+			continue
+		}
+
+		// Only get the namespace labels once, and only if required.
+		if !nsLabelsLoaded {
+			l, err := n.nsLabelsGetter.GetNamespaceLabels(ctx, namespace)
+			if err != nil {
+				return nil, fmt.Errorf("could not get %q namespace labels: %w", namespace, err)
+			}
+			nsLabels = labels.Set(l)
+			nsLabelsLoaded = true
+		}
+
+		if p.selector.Matches(nsLabels) {
+			return p.validator, nil
+		}
+	}
+
+	return n.defaultValidator, nil
+}
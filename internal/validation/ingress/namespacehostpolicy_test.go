@@ -0,0 +1,206 @@
+package ingress_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+type testNamespaceLabelsGetter map[string]map[string]string
+
+func (t testNamespaceLabelsGetter) GetNamespaceLabels(_ context.Context, namespace string) (map[string]string, error) {
+	l, ok := t[namespace]
+	if !ok {
+		return nil, fmt.Errorf("namespace missing")
+	}
+	return l, nil
+}
+
+func newNSHostsIngress(ns string, hosts ...string) metav1.Object {
+	ing := newHostsIngress(hosts...)
+	ing.Namespace = ns
+	return ing
+}
+
+func TestNamespaceHostPolicyValidator(t *testing.T) {
+	nsGetter := testNamespaceLabelsGetter{
+		"team-a":   {"tenant": "team-a"},
+		"team-b":   {"tenant": "team-b", "env": "prod"},
+		"team-b-2": {"tenant": "team-b", "env": "staging"},
+		"other":    {},
+	}
+
+	policies := []ingress.NamespaceHostPolicy{
+		{
+			Namespaces:   []string{"team-a", "team-a-2"},
+			HostPatterns: []string{"*.team-a.slok.dev"},
+		},
+		{
+			NamespaceSelector: "tenant=team-b,env in (prod)",
+			HostPatterns:      []string{".team-b.slok.dev"},
+		},
+		{
+			NamespaceSelector: "tenant=team-b",
+			HostPatterns:      []string{"*.staging.team-b.slok.dev"},
+		},
+	}
+
+	config := ingress.NamespaceHostPolicyValidatorConfig{
+		Policies:              policies,
+		DefaultHostPatterns:   []string{"*.apps.slok.dev"},
+		NamespaceLabelsGetter: nsGetter,
+	}
+
+	tests := map[string]struct {
+		config  ingress.NamespaceHostPolicyValidatorConfig
+		ingress metav1.Object
+		expErr  bool
+	}{
+		"Having a non ingress should return an error.": {
+			config:  config,
+			ingress: &extensionsv1beta1.Deployment{},
+			expErr:  true,
+		},
+
+		"Having an ingress on a namespace matched by name with a valid host, it should be valid.": {
+			config:  config,
+			ingress: newNSHostsIngress("team-a", "test1.team-a.slok.dev"),
+		},
+
+		"Having an ingress on a namespace matched by name with an invalid host, it should be invalid.": {
+			config:  config,
+			ingress: newNSHostsIngress("team-a", "test1.team-b.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress on a namespace matched by name, it should not require the namespace labels.": {
+			config:  config,
+			ingress: newNSHostsIngress("team-a-2", "test1.team-a.slok.dev"),
+		},
+
+		"Having an ingress on a namespace matched by selector with a valid host, it should be valid.": {
+			config:  config,
+			ingress: newNSHostsIngress("team-b", "a.b.team-b.slok.dev"),
+		},
+
+		"Having an ingress on a namespace matched by selector with an invalid host, it should be invalid.": {
+			config:  config,
+			ingress: newNSHostsIngress("team-b", "test1.team-a.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress on a namespace matched by multiple policies, the first one should be used.": {
+			config:  config,
+			ingress: newNSHostsIngress("team-b-2", "a.b.team-b.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an ingress on a namespace matched by the second policy, it should use that policy.": {
+			config:  config,
+			ingress: newNSHostsIngress("team-b-2", "test1.staging.team-b.slok.dev"),
+		},
+
+		"Having an ingress on a namespace without matching policies, it should use the default policy (valid).": {
+			config:  config,
+			ingress: newNSHostsIngress("other", "test1.apps.slok.dev"),
+		},
+
+		"Having an ingress on a namespace without matching policies, it should use the default policy (invalid).": {
+			config:  config,
+			ingress: newNSHostsIngress("other", "test1.team-a.slok.dev"),
+			expErr:  true,
+		},
+
+		"Having an error while getting the namespace labels, it should return an error.": {
+			config:  config,
+			ingress: newNSHostsIngress("missing", "test1.team-a.slok.dev"),
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			validator, err := ingress.NewNamespaceHostPolicyValidator(test.config)
+			require.NoError(err)
+
+			err = validator.Validate(context.TODO(), test.ingress)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestNewNamespaceHostPolicyValidatorInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		config ingress.NamespaceHostPolicyValidatorConfig
+	}{
+		"A policy without namespaces nor selector should be invalid.": {
+			config: ingress.NamespaceHostPolicyValidatorConfig{
+				Policies:            []ingress.NamespaceHostPolicy{{HostPatterns: []string{"slok.dev"}}},
+				DefaultHostPatterns: []string{"slok.dev"},
+			},
+		},
+
+		"A policy with an invalid selector should be invalid.": {
+			config: ingress.NamespaceHostPolicyValidatorConfig{
+				Policies:              []ingress.NamespaceHostPolicy{{NamespaceSelector: "in in in", HostPatterns: []string{"slok.dev"}}},
+				DefaultHostPatterns:   []string{"slok.dev"},
+				NamespaceLabelsGetter: testNamespaceLabelsGetter{},
+			},
+		},
+
+		"A policy with a selector without namespace labels getter should be invalid.": {
+			config: ingress.NamespaceHostPolicyValidatorConfig{
+				Policies:            []ingress.NamespaceHostPolicy{{NamespaceSelector: "tenant=team-a", HostPatterns: []string{"slok.dev"}}},
+				DefaultHostPatterns: []string{"slok.dev"},
+			},
+		},
+
+		"A policy with an invalid host pattern should be invalid.": {
+			config: ingress.NamespaceHostPolicyValidatorConfig{
+				Policies:            []ingress.NamespaceHostPolicy{{Namespaces: []string{"team-a"}, HostPatterns: []string{"test.*.slok.dev"}}},
+				DefaultHostPatterns: []string{"slok.dev"},
+			},
+		},
+
+		"A policy without host patterns should be invalid.": {
+			config: ingress.NamespaceHostPolicyValidatorConfig{
+				Policies:            []ingress.NamespaceHostPolicy{{Namespaces: []string{"team-a"}}},
+				DefaultHostPatterns: []string{"slok.dev"},
+			},
+		},
+
+		"Missing default host patterns should be invalid.": {
+			config: ingress.NamespaceHostPolicyValidatorConfig{
+				Policies: []ingress.NamespaceHostPolicy{{Namespaces: []string{"team-a"}, HostPatterns: []string{"slok.dev"}}},
+			},
+		},
+
+		"An invalid default host pattern should be invalid.": {
+			config: ingress.NamespaceHostPolicyValidatorConfig{
+				DefaultHostPatterns: []string{"test.*.slok.dev"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ingress.NewNamespaceHostPolicyValidator(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
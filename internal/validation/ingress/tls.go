@@ -0,0 +1,123 @@
+package ingress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewTLSValidator returns a new validator that checks the ingress TLS section:
+// - All the rule hosts are covered by a TLS entry (wildcard TLS hosts cover a single label).
+// - All the TLS hosts match a rule host.
+// - All the TLS entries have a secret name.
+// - The ingress has the required annotations (e.g: cert-manager issuer), if any.
+// It knows how to handle different ingress types.
+// If the received object is not an ingress then will return `ErrNotIngress` error.
+func NewTLSValidator(requiredAnnotations []string) Validator {
+	return tlsValidator{requiredAnnotations: requiredAnnotations}
+}
+
+type tlsValidator struct {
+	requiredAnnotations []string
+}
+
+func (v tlsValidator) Validate(ctx context.Context, obj metav1.Object) error {
+	hosts, err := getHosts(obj)
+	if err != nil {
+		return err
+	}
+
+	tlss, err := getTLS(obj)
+	if err != nil {
+		return err
+	}
+
+	errs := []string{}
+
+	tlsHosts := []string{}
+	for i, t := range tlss {
+		if t.secretName == "" {
+			errs = append(errs, fmt.Sprintf("tls entry %d secret name is missing", i))
+		}
+		tlsHosts = append(tlsHosts, t.hosts...)
+	}
+
+	// Check all the rule hosts are covered by TLS.
+	for _, host := range hosts {
+		// Rules without host, can't be covered by TLS hosts.
+		if host == "" {
+			continue
+		}
+
+		if !tlsCovers(tlsHosts, host) {
+			errs = append(errs, fmt.Sprintf("host %s is not covered by tls", host))
+		}
+	}
+
+	// Check all the TLS hosts match the rules.
+	for _, tlsHost := range tlsHosts {
+		if !tlsMatchesAnyHost(tlsHost, hosts) {
+			errs = append(errs, fmt.Sprintf("tls host %s doesn't match any rule host", tlsHost))
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	for _, a := range v.requiredAnnotations {
+		if _, ok := annotations[a]; !ok {
+			errs = append(errs, fmt.Sprintf("required annotation %s is missing", a))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// tlsCovers returns true if any of the TLS hosts covers the host.
+func tlsCovers(tlsHosts []string, host string) bool {
+	for _, tlsHost := range tlsHosts {
+		if tlsHostMatches(tlsHost, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tlsMatchesAnyHost returns true if the TLS host matches any of the hosts.
+func tlsMatchesAnyHost(tlsHost string, hosts []string) bool {
+	for _, host := range hosts {
+		if tlsHostMatches(tlsHost, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tlsHostMatches returns true if the TLS host matches the host, wildcard TLS hosts
+// match a single label like TLS certificates (e.g: `*.example.com` matches `a.example.com`).
+func tlsHostMatches(tlsHost, host string) bool {
+	tlsLabels := splitHost(tlsHost)
+	hostLabels := splitHost(host)
+	if len(tlsLabels) == 0 || len(tlsLabels) != len(hostLabels) {
+		return false
+	}
+
+	for i, l := range tlsLabels {
+		if i == 0 && l == "*" {
+			continue
+		}
+
+		if l != hostLabels[i] {
+			return false
+		}
+	}
+
+	return true
+}
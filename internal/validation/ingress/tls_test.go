@@ -0,0 +1,144 @@
+package ingress_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/ingress"
+)
+
+func TestTLSValidator(t *testing.T) {
+	tests := map[string]struct {
+		requiredAnnotations []string
+		ingress             metav1.Object
+		expErr              bool
+	}{
+		"Having a non ingress should return an error.": {
+			ingress: &extensionsv1beta1.Deployment{},
+			expErr:  true,
+		},
+
+		"Having an ingress (extensions/v1beta1) with all hosts covered by TLS, it should be valid.": {
+			ingress: &extensionsv1beta1.Ingress{
+				Spec: extensionsv1beta1.IngressSpec{
+					Rules: []extensionsv1beta1.IngressRule{{Host: "test1.slok.dev"}},
+					TLS:   []extensionsv1beta1.IngressTLS{{Hosts: []string{"test1.slok.dev"}, SecretName: "test1"}},
+				},
+			},
+		},
+
+		"Having an ingress (networking/v1beta1) with a host not covered by TLS, it should be invalid.": {
+			ingress: &networkingv1beta1.Ingress{
+				Spec: networkingv1beta1.IngressSpec{
+					Rules: []networkingv1beta1.IngressRule{{Host: "test1.slok.dev"}},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress with multiple hosts covered by multiple TLS entries, it should be valid.": {
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "test1.slok.dev"}, {Host: "test2.slok.dev"}},
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test1.slok.dev"}, SecretName: "test1"},
+						{Hosts: []string{"test2.slok.dev"}, SecretName: "test2"},
+					},
+				},
+			},
+		},
+
+		"Having an ingress with a host not covered by TLS, it should be invalid.": {
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "test1.slok.dev"}, {Host: "test2.slok.dev"}},
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"test1.slok.dev"}, SecretName: "test1"}},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress with hosts covered by a wildcard TLS host, it should be valid.": {
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "test1.slok.dev"}, {Host: "test2.slok.dev"}},
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"*.slok.dev"}, SecretName: "test"}},
+				},
+			},
+		},
+
+		"Having an ingress with a multiple label subdomain host and a wildcard TLS host, it should be invalid.": {
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "a.test1.slok.dev"}},
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"*.slok.dev"}, SecretName: "test"}},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress with a TLS host that doesn't match the rules, it should be invalid.": {
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "test1.slok.dev"}},
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"test1.slok.dev", "test2.slok.dev"}, SecretName: "test"}},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress with a TLS entry without secret name, it should be invalid.": {
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "test1.slok.dev"}},
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"test1.slok.dev"}}},
+				},
+			},
+			expErr: true,
+		},
+
+		"Having an ingress with required annotations, it should be valid.": {
+			requiredAnnotations: []string{"cert-manager.io/cluster-issuer"},
+			ingress: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"},
+				},
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "test1.slok.dev"}},
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"test1.slok.dev"}, SecretName: "test1"}},
+				},
+			},
+		},
+
+		"Having an ingress without required annotations, it should be invalid.": {
+			requiredAnnotations: []string{"cert-manager.io/cluster-issuer"},
+			ingress: &networkingv1.Ingress{
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{Host: "test1.slok.dev"}},
+					TLS:   []networkingv1.IngressTLS{{Hosts: []string{"test1.slok.dev"}, SecretName: "test1"}},
+				},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := ingress.NewTLSValidator(test.requiredAnnotations).Validate(context.TODO(), test.ingress)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"context"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+)
+
+// ServiceMonitorValidator knows how to validate a service monitor.
+type ServiceMonitorValidator interface {
+	Validate(ctx context.Context, sm *monitoringv1.ServiceMonitor) error
+}
+
+// DummyServiceMonitorValidator is a ServiceMonitorValidator that doesn't do anything.
+var DummyServiceMonitorValidator ServiceMonitorValidator = dummyServiceMonitorValidator(0)
+
+type dummyServiceMonitorValidator int
+
+func (dummyServiceMonitorValidator) Validate(_ context.Context, _ *monitoringv1.ServiceMonitor) error {
+	return nil
+}
+
+// PrometheusRuleValidator knows how to validate a Prometheus rule.
+type PrometheusRuleValidator interface {
+	Validate(ctx context.Context, pr *monitoringv1.PrometheusRule) error
+}
+
+// DummyPrometheusRuleValidator is a PrometheusRuleValidator that doesn't do anything.
+var DummyPrometheusRuleValidator PrometheusRuleValidator = dummyPrometheusRuleValidator(0)
+
+type dummyPrometheusRuleValidator int
+
+func (dummyPrometheusRuleValidator) Validate(_ context.Context, _ *monitoringv1.PrometheusRule) error {
+	return nil
+}
@@ -0,0 +1,113 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+)
+
+// RelabelPolicy is the policy of the relabelings and the label handling a service monitor can have.
+type RelabelPolicy struct {
+	// ForbiddenActions are the relabel actions that can't be used (e.g: `labelmap`).
+	ForbiddenActions []string
+	// ForbiddenTargetLabels are the labels that the relabelings can't replace (`replace`
+	// and `hashmod` actions) or drop (`labeldrop` and `labelkeep` actions), (e.g: `job`).
+	ForbiddenTargetLabels []string
+	// HonorLabelsNamespaces are the namespaces allowed to use `honorLabels: true`.
+	HonorLabelsNamespaces []string
+}
+
+// NewRelabelValidator returns a new validator that checks the service monitor endpoints
+// relabelings and metric relabelings using a relabel policy. It checks the relabel regexes
+// are valid, the actions and target labels are not forbidden and that only the allowed
+// namespaces use honor labels. All the violations will be returned at once.
+func NewRelabelValidator(policy RelabelPolicy) ServiceMonitorValidator {
+	forbiddenActions := map[string]bool{}
+	for _, a := range policy.ForbiddenActions {
+		forbiddenActions[strings.ToLower(a)] = true
+	}
+
+	honorLabelsNS := map[string]bool{}
+	for _, ns := range policy.HonorLabelsNamespaces {
+		honorLabelsNS[ns] = true
+	}
+
+	return relabelValidator{
+		forbiddenActions: forbiddenActions,
+		forbiddenLabels:  policy.ForbiddenTargetLabels,
+		honorLabelsNS:    honorLabelsNS,
+	}
+}
+
+type relabelValidator struct {
+	forbiddenActions map[string]bool
+	forbiddenLabels  []string
+	honorLabelsNS    map[string]bool
+}
+
+func (r relabelValidator) Validate(_ context.Context, sm *monitoringv1.ServiceMonitor) error {
+	errs := []string{}
+	for i, e := range sm.Spec.Endpoints {
+		if e.HonorLabels && !r.honorLabelsNS[sm.Namespace] {
+			errs = append(errs, fmt.Sprintf("endpoint %d honorLabels is not allowed on %q namespace", i, sm.Namespace))
+		}
+
+		errs = append(errs, r.validateRelabelings(fmt.Sprintf("endpoint %d relabeling", i), e.RelabelConfigs)...)
+		errs = append(errs, r.validateRelabelings(fmt.Sprintf("endpoint %d metric relabeling", i), e.MetricRelabelConfigs)...)
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// validateRelabelings returns the violations of the relabelings.
+func (r relabelValidator) validateRelabelings(prefix string, rcs []*monitoringv1.RelabelConfig) []string {
+	errs := []string{}
+	for i, rc := range rcs {
+		if rc == nil {
+			continue
+		}
+		name := fmt.Sprintf("%s %d", prefix, i)
+
+		// Same defaults as Prometheus.
+		action := strings.ToLower(rc.Action)
+		if action == "" {
+			action = "replace"
+		}
+		regex := rc.Regex
+		if regex == "" {
+			regex = "(.*)"
+		}
+
+		if r.forbiddenActions[action] {
+			errs = append(errs, fmt.Sprintf("%s action %q is forbidden", name, action))
+		}
+
+		// Prometheus regexes are fully anchored.
+		rgx, err := regexp.Compile(`^(?:` + regex + `)$`)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s regex %q is not valid: %s", name, rc.Regex, err))
+			continue
+		}
+
+		for _, l := range r.forbiddenLabels {
+			switch {
+			case (action == "replace" || action == "hashmod") && rc.TargetLabel == l:
+				errs = append(errs, fmt.Sprintf("%s target label %q is forbidden", name, l))
+			case action == "labeldrop" && rgx.MatchString(l):
+				errs = append(errs, fmt.Sprintf("%s drops the forbidden %q label", name, l))
+			case action == "labelkeep" && !rgx.MatchString(l):
+				errs = append(errs, fmt.Sprintf("%s drops the forbidden %q label", name, l))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,116 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/prometheus"
+)
+
+func newRelabelServiceMonitor(ns string, endpoints ...monitoringv1.Endpoint) *monitoringv1.ServiceMonitor {
+	return &monitoringv1.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: ns},
+		Spec:       monitoringv1.ServiceMonitorSpec{Endpoints: endpoints},
+	}
+}
+
+func TestRelabelValidator(t *testing.T) {
+	policy := prometheus.RelabelPolicy{
+		ForbiddenActions:      []string{"labelmap"},
+		ForbiddenTargetLabels: []string{"job", "namespace"},
+		HonorLabelsNamespaces: []string{"monitoring"},
+	}
+
+	tests := map[string]struct {
+		policy    prometheus.RelabelPolicy
+		servMon   *monitoringv1.ServiceMonitor
+		expErr    bool
+		expErrMsg string
+	}{
+		"Having a service monitor without relabelings, it should be valid.": {
+			policy:  policy,
+			servMon: newRelabelServiceMonitor("test", monitoringv1.Endpoint{Port: "http"}),
+		},
+
+		"Having a service monitor with valid relabelings, it should be valid.": {
+			policy: policy,
+			servMon: newRelabelServiceMonitor("test", monitoringv1.Endpoint{
+				RelabelConfigs: []*monitoringv1.RelabelConfig{
+					{SourceLabels: []string{"__meta_kubernetes_pod_name"}, TargetLabel: "pod"},
+					{Action: "labeldrop", Regex: "tmp_.*"},
+					{Action: "labelkeep", Regex: "job|namespace|pod"},
+				},
+				MetricRelabelConfigs: []*monitoringv1.RelabelConfig{
+					{Action: "drop", SourceLabels: []string{"__name__"}, Regex: "go_.*"},
+				},
+			}),
+		},
+
+		"Having a service monitor with an invalid regex, it should be invalid.": {
+			policy: policy,
+			servMon: newRelabelServiceMonitor("test", monitoringv1.Endpoint{
+				MetricRelabelConfigs: []*monitoringv1.RelabelConfig{
+					{Action: "drop", Regex: "go_(.*"},
+				},
+			}),
+			expErr:    true,
+			expErrMsg: "endpoint 0 metric relabeling 0 regex \"go_(.*\" is not valid: error parsing regexp: missing closing ): `^(?:go_(.*)$`",
+		},
+
+		"Having a service monitor with forbidden actions and target labels, it should be invalid.": {
+			policy: policy,
+			servMon: newRelabelServiceMonitor("test",
+				monitoringv1.Endpoint{
+					RelabelConfigs: []*monitoringv1.RelabelConfig{
+						{Action: "LabelMap", Regex: "__meta_kubernetes_pod_label_(.+)"},
+						{SourceLabels: []string{"__meta_kubernetes_pod_name"}, TargetLabel: "job"},
+					},
+				},
+				monitoringv1.Endpoint{
+					MetricRelabelConfigs: []*monitoringv1.RelabelConfig{
+						{Action: "hashmod", TargetLabel: "namespace", Modulus: 2},
+						{Action: "labeldrop", Regex: "jo.*"},
+						{Action: "labelkeep", Regex: "job|pod"},
+					},
+				},
+			),
+			expErr: true,
+			expErrMsg: `endpoint 0 relabeling 0 action "labelmap" is forbidden, ` +
+				`endpoint 0 relabeling 1 target label "job" is forbidden, ` +
+				`endpoint 1 metric relabeling 0 target label "namespace" is forbidden, ` +
+				`endpoint 1 metric relabeling 1 drops the forbidden "job" label, ` +
+				`endpoint 1 metric relabeling 2 drops the forbidden "namespace" label`,
+		},
+
+		"Having a service monitor with honor labels on a not allowed namespace, it should be invalid.": {
+			policy:    policy,
+			servMon:   newRelabelServiceMonitor("test", monitoringv1.Endpoint{HonorLabels: true}),
+			expErr:    true,
+			expErrMsg: `endpoint 0 honorLabels is not allowed on "test" namespace`,
+		},
+
+		"Having a service monitor with honor labels on an allowed namespace, it should be valid.": {
+			policy:  policy,
+			servMon: newRelabelServiceMonitor("monitoring", monitoringv1.Endpoint{HonorLabels: true}),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v := prometheus.NewRelabelValidator(test.policy)
+			err := v.Validate(context.TODO(), test.servMon)
+
+			if test.expErr {
+				assert.EqualError(err, test.expErrMsg)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
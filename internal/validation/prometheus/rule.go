@@ -0,0 +1,120 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// RulePolicy is the policy of the rules a Prometheus rule can have.
+type RulePolicy struct {
+	// RequiredAlertLabels are the labels that the alerting rules must have (e.g: `severity`).
+	RequiredAlertLabels []string
+	// RequiredAlertAnnotations are the annotations that the alerting rules must have (e.g: `summary`).
+	RequiredAlertAnnotations []string
+}
+
+// NewRuleValidator returns a new validator that checks the Prometheus rule groups using a
+// rule policy. It checks the rules PromQL expressions and `for` durations are valid, the
+// alerting rules have the required labels and annotations, and that the rule names are not
+// duplicated on the same group. All the violations will be returned at once.
+func NewRuleValidator(policy RulePolicy) PrometheusRuleValidator {
+	return ruleValidator{
+		requiredLabels: policy.RequiredAlertLabels,
+		requiredAnnots: policy.RequiredAlertAnnotations,
+	}
+}
+
+type ruleValidator struct {
+	requiredLabels []string
+	requiredAnnots []string
+}
+
+func (r ruleValidator) Validate(_ context.Context, pr *monitoringv1.PrometheusRule) error {
+	errs := []string{}
+	for _, g := range pr.Spec.Groups {
+		names := map[string]int{}
+		for i, rule := range g.Rules {
+			errs = append(errs, r.validateRule(fmt.Sprintf("group %q rule %d", g.Name, i), rule)...)
+
+			name := rule.Alert
+			if name == "" {
+				name = rule.Record
+			}
+			if name != "" {
+				names[name]++
+			}
+		}
+
+		// Sorted to return the same error on every validation.
+		duplicated := []string{}
+		for name, n := range names {
+			if n > 1 {
+				duplicated = append(duplicated, name)
+			}
+		}
+		sort.Strings(duplicated)
+		for _, name := range duplicated {
+			errs = append(errs, fmt.Sprintf("group %q rule name %q is duplicated", g.Name, name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// validateRule returns the violations of the rule.
+func (r ruleValidator) validateRule(name string, rule monitoringv1.Rule) []string {
+	errs := []string{}
+
+	switch {
+	case rule.Alert == "" && rule.Record == "":
+		errs = append(errs, fmt.Sprintf("%s must be an alerting or a recording rule", name))
+	case rule.Alert != "" && rule.Record != "":
+		errs = append(errs, fmt.Sprintf("%s can't be an alerting and a recording rule at the same time", name))
+	}
+
+	expr := rule.Expr.String()
+	if expr == "" {
+		errs = append(errs, fmt.Sprintf("%s expression is missing", name))
+	} else if _, err := parser.ParseExpr(expr); err != nil {
+		errs = append(errs, fmt.Sprintf("%s expression %q is not valid: %s", name, expr, err))
+	}
+
+	// The next checks are only for the alerting rules.
+	if rule.Alert == "" {
+		if rule.For != "" {
+			errs = append(errs, fmt.Sprintf("%s for is only allowed on alerting rules", name))
+		}
+		return errs
+	}
+
+	if rule.For != "" {
+		if _, err := model.ParseDuration(rule.For); err != nil {
+			errs = append(errs, fmt.Sprintf("%s for %q is not valid: %s", name, rule.For, err))
+		}
+	}
+
+	for _, l := range r.requiredLabels {
+		if rule.Labels[l] == "" {
+			errs = append(errs, fmt.Sprintf("%s label %q is required", name, l))
+		}
+	}
+
+	for _, a := range r.requiredAnnots {
+		if rule.Annotations[a] == "" {
+			errs = append(errs, fmt.Sprintf("%s annotation %q is required", name, a))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,165 @@
+package prometheus_test
+
+import (
+	"context"
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/slok/k8s-webhook-example/internal/validation/prometheus"
+)
+
+func newPrometheusRule(groups ...monitoringv1.RuleGroup) *monitoringv1.PrometheusRule {
+	return &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec:       monitoringv1.PrometheusRuleSpec{Groups: groups},
+	}
+}
+
+func newAlertingRule(name, expr, forDuration string) monitoringv1.Rule {
+	return monitoringv1.Rule{
+		Alert:       name,
+		Expr:        intstr.FromString(expr),
+		For:         forDuration,
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": "test", "runbook_url": "https://runbooks.example.com/test"},
+	}
+}
+
+func TestRuleValidator(t *testing.T) {
+	policy := prometheus.RulePolicy{
+		RequiredAlertLabels:      []string{"severity"},
+		RequiredAlertAnnotations: []string{"runbook_url", "summary"},
+	}
+
+	tests := map[string]struct {
+		policy    prometheus.RulePolicy
+		promRule  *monitoringv1.PrometheusRule
+		expErr    bool
+		expErrMsg string
+	}{
+		"Having a Prometheus rule without groups, it should be valid.": {
+			policy:   policy,
+			promRule: newPrometheusRule(),
+		},
+
+		"Having a Prometheus rule with valid rules, it should be valid.": {
+			policy: policy,
+			promRule: newPrometheusRule(monitoringv1.RuleGroup{
+				Name: "test",
+				Rules: []monitoringv1.Rule{
+					{Record: "job:http_requests:rate5m", Expr: intstr.FromString(`sum by (job) (rate(http_requests_total[5m]))`)},
+					newAlertingRule("HighErrorRate", `job:http_requests:rate5m{code=~"5.."} > 10`, "10m"),
+					newAlertingRule("Down", `up == 0`, ""),
+				},
+			}),
+		},
+
+		"Having a Prometheus rule with invalid expressions, it should be invalid.": {
+			policy: policy,
+			promRule: newPrometheusRule(monitoringv1.RuleGroup{
+				Name: "test",
+				Rules: []monitoringv1.Rule{
+					{Record: "job:http_requests:rate5m", Expr: intstr.FromString(`sum by (job) (rate(http_requests_total[5m])`)},
+					newAlertingRule("Down", ``, ""),
+				},
+			}),
+			expErr: true,
+			expErrMsg: `group "test" rule 0 expression "sum by (job) (rate(http_requests_total[5m])" is not valid: 1:44: parse error: unclosed left parenthesis, ` +
+				`group "test" rule 1 expression is missing`,
+		},
+
+		"Having a Prometheus rule with invalid for durations, it should be invalid.": {
+			policy: policy,
+			promRule: newPrometheusRule(monitoringv1.RuleGroup{
+				Name: "test",
+				Rules: []monitoringv1.Rule{
+					newAlertingRule("Down", `up == 0`, "10 minutes"),
+					{Record: "job:up:sum", Expr: intstr.FromString(`sum by (job) (up)`), For: "5m"},
+				},
+			}),
+			expErr: true,
+			expErrMsg: `group "test" rule 0 for "10 minutes" is not valid: not a valid duration string: "10 minutes", ` +
+				`group "test" rule 1 for is only allowed on alerting rules`,
+		},
+
+		"Having a Prometheus rule with alerts without the required labels and annotations, it should be invalid.": {
+			policy: policy,
+			promRule: newPrometheusRule(monitoringv1.RuleGroup{
+				Name: "test",
+				Rules: []monitoringv1.Rule{
+					{Alert: "Down", Expr: intstr.FromString(`up == 0`), Annotations: map[string]string{"summary": "test"}},
+				},
+			}),
+			expErr: true,
+			expErrMsg: `group "test" rule 0 label "severity" is required, ` +
+				`group "test" rule 0 annotation "runbook_url" is required`,
+		},
+
+		"Having a Prometheus rule with recording rules without the alert required labels and annotations, it should be valid.": {
+			policy: policy,
+			promRule: newPrometheusRule(monitoringv1.RuleGroup{
+				Name: "test",
+				Rules: []monitoringv1.Rule{
+					{Record: "job:up:sum", Expr: intstr.FromString(`sum by (job) (up)`)},
+				},
+			}),
+		},
+
+		"Having a Prometheus rule with rules without name or with both names, it should be invalid.": {
+			policy: prometheus.RulePolicy{},
+			promRule: newPrometheusRule(monitoringv1.RuleGroup{
+				Name: "test",
+				Rules: []monitoringv1.Rule{
+					{Expr: intstr.FromString(`up == 0`)},
+					{Alert: "Down", Record: "job:up", Expr: intstr.FromString(`up == 0`)},
+				},
+			}),
+			expErr: true,
+			expErrMsg: `group "test" rule 0 must be an alerting or a recording rule, ` +
+				`group "test" rule 1 can't be an alerting and a recording rule at the same time`,
+		},
+
+		"Having a Prometheus rule with duplicated rule names on the same group, it should be invalid.": {
+			policy: policy,
+			promRule: newPrometheusRule(
+				monitoringv1.RuleGroup{
+					Name: "test",
+					Rules: []monitoringv1.Rule{
+						newAlertingRule("Down", `up == 0`, ""),
+						newAlertingRule("Down", `up{job="test"} == 0`, ""),
+						{Record: "job:up:sum", Expr: intstr.FromString(`sum by (job) (up)`)},
+						{Record: "job:up:sum", Expr: intstr.FromString(`sum by (job) (up)`)},
+					},
+				},
+				monitoringv1.RuleGroup{
+					Name: "test2",
+					Rules: []monitoringv1.Rule{
+						newAlertingRule("Down", `up == 0`, ""),
+					},
+				},
+			),
+			expErr: true,
+			expErrMsg: `group "test" rule name "Down" is duplicated, ` +
+				`group "test" rule name "job:up:sum" is duplicated`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			v := prometheus.NewRuleValidator(test.policy)
+			err := v.Validate(context.TODO(), test.promRule)
+
+			if test.expErr {
+				assert.EqualError(err, test.expErrMsg)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}